@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/markmdev/reflex/internal"
+)
+
+func runAnnotate(args []string) error {
+	configPath := ""
+	dryRun, yes := false, false
+	var paths []string
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--config":
+			if i+1 < len(args) {
+				configPath = args[i+1]
+				i++
+			}
+		case "--dry-run":
+			dryRun = true
+		case "--yes", "-y":
+			yes = true
+		default:
+			paths = append(paths, args[i])
+		}
+	}
+
+	cfg, err := internal.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	root, _ := os.Getwd()
+	files, err := internal.AnnotationCandidates(root, paths, cfg.Discover)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		fmt.Println("Every doc already has a summary and read_when hints.")
+		return nil
+	}
+
+	annotator := &internal.Annotator{Config: cfg}
+	answers := bufio.NewReader(os.Stdin)
+	written, skipped, failed := 0, 0, 0
+	for _, rel := range files {
+		data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(rel)))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "[reflex] annotating %s\n", rel)
+		ann, err := annotator.Propose(rel, string(data))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[reflex] %s: %v\n", rel, err)
+			failed++
+			continue
+		}
+		fmt.Print(ann.Diff())
+		if dryRun {
+			continue
+		}
+
+		if !yes {
+			fmt.Printf("Apply to %s? [y]es, [n]o, [q]uit: ", rel)
+			line, err := answers.ReadString('\n')
+			answer := strings.ToLower(strings.TrimSpace(line))
+			if answer == "q" || (err != nil && answer == "") {
+				fmt.Println()
+				break
+			}
+			if answer != "y" && answer != "yes" {
+				skipped++
+				continue
+			}
+		}
+		if err := ann.Write(root); err != nil {
+			return err
+		}
+		written++
+	}
+
+	if dryRun {
+		fmt.Printf("%d docs would be annotated (dry run), %d failed\n", len(files)-failed, failed)
+	} else {
+		fmt.Printf("%d annotated, %d skipped, %d failed\n", written, skipped, failed)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d docs could not be annotated", failed)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/markmdev/reflex/internal"
+)
+
+func runCache(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: reflex cache <stats|clear>")
+	}
+	switch args[0] {
+	case "stats":
+		return cacheStats()
+	case "clear":
+		return cacheClear()
+	default:
+		return fmt.Errorf("unknown cache command: %s\n\nCommands: stats, clear", args[0])
+	}
+}
+
+func cacheStats() error {
+	cfg, store, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	stats, err := store.CacheStats()
+	if err != nil {
+		return fmt.Errorf("failed to read cache: %w", err)
+	}
+
+	// Hit rate comes from the log: cached entries vs. entries that called the LLM
+	hits, misses := 0, 0
+	entries, _ := store.ReadLog(internal.LogQuery{})
+	for _, e := range entries {
+		switch e.Status {
+		case "cached":
+			hits++
+		case "ok":
+			misses++
+		}
+	}
+
+	enabled := "yes"
+	if cfg.Cache.Disabled {
+		enabled = "no"
+	}
+	fmt.Printf("Cache:\n")
+	fmt.Printf("  enabled:     %s\n", enabled)
+	fmt.Printf("  ttl:         %s\n", cfg.Cache.TTL)
+	fmt.Printf("  max entries: %d\n", cfg.Cache.MaxEntries)
+	fmt.Printf("  entries:     %d (%d expired)\n", stats.Entries, stats.Expired)
+	fmt.Printf("  size:        %.1f KB\n", float64(stats.Bytes)/1024)
+	if stats.Entries > 0 {
+		fmt.Printf("  oldest:      %s\n", stats.Oldest.Local().Format("2006-01-02 15:04"))
+		fmt.Printf("  newest:      %s\n", stats.Newest.Local().Format("2006-01-02 15:04"))
+	}
+	if hits+misses > 0 {
+		fmt.Printf("  hit rate:    %d/%d (%.0f%%) in logs\n", hits, hits+misses, 100*float64(hits)/float64(hits+misses))
+	}
+	if cfg.Storage.Backend == "sqlite" {
+		fmt.Printf("\n  %s\n", storeLocation(cfg))
+	} else {
+		fmt.Printf("\n  %s\n", internal.CacheDir())
+	}
+	return nil
+}
+
+func cacheClear() error {
+	_, store, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	n, err := store.CacheClear()
+	if err != nil {
+		return fmt.Errorf("failed to clear cache: %w", err)
+	}
+	fmt.Printf("Removed %d cached decision(s).\n", n)
+	return nil
+}
@@ -17,7 +17,7 @@ func runConfig(args []string) error {
 		return configShow()
 	case "set":
 		if len(args) < 3 {
-			return fmt.Errorf("usage: reflex config set <key> <value>\n\nKeys: api-key, model, base-url")
+			return fmt.Errorf("usage: reflex config set <key> <value>\n\nKeys: api-key, model, base-url, storage")
 		}
 		return configSet(args[1], args[2])
 	case "reset":
@@ -48,6 +48,18 @@ func configShow() error {
 	fmt.Printf("  api-key:  %s\n", keyDisplay)
 	fmt.Printf("  model:    %s\n", p.Model)
 	fmt.Printf("  base-url: %s\n", p.BaseURL)
+	if p.Mode != "" {
+		dir := p.FixtureDir
+		if dir == "" {
+			dir = internal.DefaultFixtureDir()
+		}
+		fmt.Printf("  mode:     %s (%s)\n", p.Mode, dir)
+	}
+	backend := cfg.Storage.Backend
+	if backend == "" {
+		backend = "jsonl"
+	}
+	fmt.Printf("\nStorage:   %s\n", backend)
 	fmt.Printf("\nGlobal config: %s\n", internal.GlobalConfigPath())
 	return nil
 }
@@ -62,8 +74,13 @@ func configSet(key, value string) error {
 		cfg.Provider.Model = value
 	case "base-url", "base_url":
 		cfg.Provider.BaseURL = value
+	case "storage":
+		if value != "jsonl" && value != "sqlite" {
+			return fmt.Errorf("invalid storage backend: %s (use jsonl or sqlite)", value)
+		}
+		cfg.Storage.Backend = value
 	default:
-		return fmt.Errorf("unknown key: %s\n\nValid keys: api-key, model, base-url", key)
+		return fmt.Errorf("unknown key: %s\n\nValid keys: api-key, model, base-url, storage", key)
 	}
 
 	if err := internal.SaveGlobalConfig(cfg); err != nil {
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/markmdev/reflex/internal"
+)
+
+func runDiscover(args []string) error {
+	root, configPath := "", ""
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--config":
+			if i+1 < len(args) {
+				configPath = args[i+1]
+				i++
+			}
+		default:
+			root = args[i]
+		}
+	}
+	if root == "" {
+		root, _ = os.Getwd()
+	}
+
+	cfg, err := internal.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	registry, err := internal.Discover(root, cfg.Discover)
+	if err != nil {
+		return err
+	}
+	out, _ := json.Marshal(registry)
+	fmt.Println(string(out))
+	return nil
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/markmdev/reflex/internal"
+)
+
+func runEval(args []string) error {
+	var path, configPath string
+	mock, asJSON := false, false
+	minF1 := -1.0
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--mock":
+			mock = true
+		case "--json":
+			asJSON = true
+		case "--config":
+			if i+1 < len(args) {
+				configPath = args[i+1]
+				i++
+			}
+		case "--min-f1":
+			if i+1 < len(args) {
+				v, err := strconv.ParseFloat(args[i+1], 64)
+				if err != nil {
+					return fmt.Errorf("invalid --min-f1: %s", args[i+1])
+				}
+				minF1 = v
+				i++
+			}
+		default:
+			path = args[i]
+		}
+	}
+	if path == "" {
+		return fmt.Errorf("usage: reflex eval <dataset.yaml|dataset.jsonl> [--mock] [--json] [--min-f1 0.8] [--config path]")
+	}
+
+	cfg, err := internal.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	ds, err := internal.LoadEvalDataset(path)
+	if err != nil {
+		return fmt.Errorf("failed to load dataset: %w", err)
+	}
+	if len(ds.Cases) == 0 {
+		return fmt.Errorf("dataset %s has no cases", path)
+	}
+
+	report := internal.RunEval(ds, cfg, nil, mock)
+
+	if asJSON {
+		out, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(out))
+	} else {
+		printEvalReport(report)
+	}
+
+	if minF1 >= 0 && report.Summary.F1 < minF1 {
+		return fmt.Errorf("F1 %.3f is below --min-f1 %.3f", report.Summary.F1, minF1)
+	}
+	if report.Summary.Errors > 0 {
+		fmt.Fprintf(os.Stderr, "[reflex] %d case(s) errored\n", report.Summary.Errors)
+	}
+	return nil
+}
+
+func printEvalReport(r internal.EvalReport) {
+	for _, c := range r.Cases {
+		mark := "✓"
+		if !c.Exact {
+			mark = "✗"
+		}
+		fmt.Printf("  %s  %-28s  P %.2f  R %.2f  F1 %.2f  %5dms\n",
+			mark, truncate(c.Name, 28), c.Precision, c.Recall, c.F1, c.LatencyMS)
+		if !c.Exact {
+			fmt.Printf("       expected: %s\n", listOrEmpty(c.Expected))
+			fmt.Printf("       got:      %s\n", listOrEmpty(c.Got))
+		}
+		if c.Error != "" {
+			fmt.Printf("       error: %s\n", truncate(c.Error, 80))
+		} else if c.Skipped != "" {
+			fmt.Printf("       skipped: %s\n", c.Skipped)
+		}
+	}
+
+	s := r.Summary
+	fmt.Printf("\n%d cases (%s)\n", s.Cases, r.Model)
+	fmt.Printf("  precision:             %.3f\n", s.Precision)
+	fmt.Printf("  recall:                %.3f\n", s.Recall)
+	fmt.Printf("  F1:                    %.3f\n", s.F1)
+	fmt.Printf("  exact match:           %.1f%%\n", 100*s.ExactRate)
+	fmt.Printf("  false \"nothing needed\": %.1f%%\n", 100*s.FalseNothingRate)
+	fmt.Printf("  false injections:      %.1f%%\n", 100*s.FalseInjectRate)
+	fmt.Printf("  latency:               avg %dms, max %dms\n", s.AvgLatencyMS, s.MaxLatencyMS)
+	if s.Errors > 0 {
+		fmt.Printf("  errors:                %d\n", s.Errors)
+	}
+}
+
+func listOrEmpty(items []string) string {
+	if len(items) == 0 {
+		return "(nothing)"
+	}
+	return strings.Join(items, ", ")
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/markmdev/reflex/internal"
+)
+
+const feedbackUsage = `usage: reflex feedback <log-id|last> --good | --bad [items...] | --missed <items...> [--note text]
+       reflex feedback --stdin          (JSON feedback objects, one per line)
+       reflex feedback export [--out dataset.jsonl]`
+
+func runFeedback(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf(feedbackUsage)
+	}
+	_, store, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	switch args[0] {
+	case "export":
+		return feedbackExport(store, args[1:])
+	case "--stdin":
+		return feedbackStdin(store)
+	}
+
+	f := internal.Feedback{LogID: args[0]}
+	for i := 1; i < len(args); i++ {
+		switch args[i] {
+		case "--good":
+			f.Verdict = internal.FeedbackGood
+		case "--bad":
+			f.Verdict = internal.FeedbackBad
+		case "--missed":
+			f.Verdict = internal.FeedbackMissed
+		case "--note":
+			if i+1 < len(args) {
+				f.Note = args[i+1]
+				i++
+			}
+		default:
+			if strings.HasPrefix(args[i], "--") {
+				return fmt.Errorf(feedbackUsage)
+			}
+			f.Items = append(f.Items, args[i])
+		}
+	}
+	if f.LogID == "last" {
+		entries, err := store.ReadLog(internal.LogQuery{Limit: 1})
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 || entries[0].ID == "" {
+			return fmt.Errorf("the latest log entry has no id")
+		}
+		f.LogID = entries[0].ID
+	}
+	if err := saveFeedback(store, f); err != nil {
+		return err
+	}
+	fmt.Printf("Recorded %s feedback for %s\n", f.Verdict, f.LogID)
+	return nil
+}
+
+// saveFeedback validates f against the log and stores it.
+func saveFeedback(store internal.Store, f internal.Feedback) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+	entries, err := store.ReadLog(internal.LogQuery{ID: f.LogID})
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("no log entry with id %s", f.LogID)
+	}
+	if f.Timestamp == "" {
+		f.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	}
+	return store.AppendFeedback(f)
+}
+
+// feedbackStdin records feedback sent by hooks, one JSON object per line.
+func feedbackStdin(store internal.Store) error {
+	scanner := bufio.NewScanner(os.Stdin)
+	n := 0
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var f internal.Feedback
+		if err := json.Unmarshal([]byte(text), &f); err != nil {
+			return fmt.Errorf("stdin:%d: %w", line, err)
+		}
+		if err := saveFeedback(store, f); err != nil {
+			return fmt.Errorf("stdin:%d: %w", line, err)
+		}
+		n++
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "[reflex] recorded %d feedback labels\n", n)
+	return nil
+}
+
+// feedbackExport writes labeled decisions as an eval dataset in JSONL.
+func feedbackExport(store internal.Store, args []string) error {
+	out := os.Stdout
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--out" && i+1 < len(args) {
+			f, err := os.Create(args[i+1])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			out = f
+			i++
+		} else {
+			return fmt.Errorf(feedbackUsage)
+		}
+	}
+
+	feedback, err := store.ReadFeedback()
+	if err != nil {
+		return err
+	}
+	entries, err := store.ReadLog(internal.LogQuery{})
+	if err != nil {
+		return err
+	}
+	ds, skipped := internal.FeedbackDataset(entries, feedback)
+	enc := json.NewEncoder(out)
+	for _, c := range ds.Cases {
+		if err := enc.Encode(c); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(os.Stderr, "[reflex] exported %d cases", len(ds.Cases))
+	if skipped > 0 {
+		fmt.Fprintf(os.Stderr, "; %d labeled decisions skipped (log entry rotated out or recorded without log.record_input)", skipped)
+	}
+	fmt.Fprintln(os.Stderr)
+	return nil
+}
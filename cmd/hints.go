@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/markmdev/reflex/internal"
+)
+
+const hintsUsage = "usage: reflex hints suggest [--since 30d] [--min-count N] [--all] [--json] [--config path]"
+
+func runHints(args []string) error {
+	if len(args) == 0 || args[0] != "suggest" {
+		return fmt.Errorf(hintsUsage)
+	}
+	var configPath string
+	var since time.Time
+	opts := internal.HintsOptions{}
+	all, asJSON := false, false
+	for i := 1; i < len(args); i++ {
+		switch args[i] {
+		case "--since":
+			if i+1 < len(args) {
+				t, err := parseSince(args[i+1])
+				if err != nil {
+					return err
+				}
+				since = t
+				i++
+			}
+		case "--min-count":
+			if i+1 < len(args) {
+				n, err := strconv.Atoi(args[i+1])
+				if err != nil || n < 1 {
+					return fmt.Errorf("invalid --min-count: %s", args[i+1])
+				}
+				opts.MinCount = n
+				i++
+			}
+		case "--all":
+			all = true
+		case "--json":
+			asJSON = true
+		case "--config":
+			if i+1 < len(args) {
+				configPath = args[i+1]
+				i++
+			}
+		default:
+			return fmt.Errorf(hintsUsage)
+		}
+	}
+
+	cfg, err := internal.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	root, _ := os.Getwd()
+	registry, err := internal.Discover(root, cfg.Discover)
+	if err != nil {
+		return err
+	}
+	store, err := internal.OpenStore(cfg)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	entries, err := store.ReadLog(internal.LogQuery{Since: since})
+	if err != nil {
+		return err
+	}
+
+	// The log is shared by every project; keep this one's decisions unless --all
+	if !all {
+		kept := entries[:0]
+		for _, e := range entries {
+			if rel, err := filepath.Rel(root, e.CWD); err == nil && !strings.HasPrefix(rel, "..") {
+				kept = append(kept, e)
+			}
+		}
+		entries = kept
+	}
+
+	report := internal.SuggestHints(entries, registry, opts)
+	if asJSON {
+		out, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(out))
+		return nil
+	}
+
+	fmt.Printf("Mined %d log entries", report.Entries-report.NoInput)
+	if report.NoInput > 0 {
+		fmt.Printf(" (%d without recorded input; set log.record_input: true)", report.NoInput)
+	}
+	fmt.Println()
+	if len(report.Docs) == 0 {
+		fmt.Println("No new read_when hints to suggest.")
+		return nil
+	}
+	for _, d := range report.Docs {
+		fmt.Printf("\n%s  (injected %d, missed %d)\n", d.Path, d.Selected, d.Missed)
+		fmt.Printf("  current: %s\n", strings.Join(d.ReadWhen, ", "))
+		for _, s := range d.Suggestions {
+			fmt.Printf("  + %-30s injected %d, missed %d\n", s.Phrase, s.Selected, s.Missed)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/markmdev/reflex/internal"
+)
+
+func runLint(args []string) error {
+	root, configPath := "", ""
+	asJSON, strict := false, false
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--config":
+			if i+1 < len(args) {
+				configPath = args[i+1]
+				i++
+			}
+		case "--json":
+			asJSON = true
+		case "--strict":
+			strict = true
+		default:
+			root = args[i]
+		}
+	}
+	if root == "" {
+		root, _ = os.Getwd()
+	}
+
+	cfg, err := internal.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	report, err := internal.Lint(root, cfg)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		out, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(out))
+	} else {
+		for _, is := range report.Issues {
+			path := is.Path
+			if path == "" {
+				path = "(registry)"
+			}
+			fmt.Printf("%-7s %s: %s [%s]\n", is.Severity, path, is.Message, is.Rule)
+		}
+		if len(report.Issues) > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%d docs, %d skills, ~%d registry tokens: %d errors, %d warnings\n",
+			report.Docs, report.Skills, report.RegistryTokens, report.Errors, report.Warnings)
+	}
+
+	if report.Errors > 0 {
+		return fmt.Errorf("lint failed: %d errors", report.Errors)
+	}
+	if strict && report.Warnings > 0 {
+		return fmt.Errorf("lint failed: %d warnings (--strict)", report.Warnings)
+	}
+	return nil
+}
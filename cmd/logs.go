@@ -1,10 +1,7 @@
 package cmd
 
 import (
-	"bufio"
-	"encoding/json"
 	"fmt"
-	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -23,40 +20,26 @@ func runLogs(args []string) error {
 		}
 	}
 
-	p := internal.LogPath()
-	f, err := os.Open(p)
+	cfg, store, err := openStore()
 	if err != nil {
-		if os.IsNotExist(err) {
-			fmt.Println("No logs yet.")
-			return nil
-		}
 		return err
 	}
-	defer f.Close()
-
-	var lines []string
-	scanner := bufio.NewScanner(f)
-	// Increase scanner buffer for large log lines
-	scanner.Buffer(make([]byte, 0, 1024*1024), 1024*1024)
-	for scanner.Scan() {
-		if line := scanner.Text(); line != "" {
-			lines = append(lines, line)
-		}
-	}
+	defer store.Close()
 
-	if len(lines) > n {
-		lines = lines[len(lines)-n:]
+	entries, err := store.ReadLog(internal.LogQuery{Limit: n})
+	if err != nil {
+		return err
 	}
 
-	if len(lines) == 0 {
+	if len(entries) == 0 {
 		fmt.Println("No logs yet.")
 		return nil
 	}
 
-	for _, line := range lines {
-		var e internal.LogEntry
-		if err := json.Unmarshal([]byte(line), &e); err != nil {
-			continue
+	trivial := 0
+	for _, e := range entries {
+		if internal.IsTrivialSkip(e.SkipReason) {
+			trivial++
 		}
 
 		ts, _ := time.Parse(time.RFC3339, e.Timestamp)
@@ -72,6 +55,10 @@ func runLogs(args []string) error {
 		switch e.Status {
 		case "ok":
 			status = "✓"
+		case "cached":
+			status = "≈"
+		case "pinned":
+			status = "•"
 		case "skipped":
 			status = "○"
 		case "error":
@@ -84,7 +71,7 @@ func runLogs(args []string) error {
 		var result string
 		if e.Error != "" {
 			result = "error: " + truncate(e.Error, 50)
-		} else if e.SkipReason != "" {
+		} else if e.SkipReason != "" && e.Status != "pinned" {
 			result = "skip: " + e.SkipReason
 		} else if e.Result != nil {
 			parts := []string{}
@@ -108,13 +95,16 @@ func runLogs(args []string) error {
 		}
 
 		// Registry size
-		regSize := len(e.Registry.Docs) + len(e.Registry.Skills)
+		regSize := e.Registry.Len()
 
-		fmt.Printf("  %s  %s  %-18s  %4dms  %dm/%dr  %s\n",
-			status, local, project, e.LatencyMS, e.MessageCount, regSize, result)
+		fmt.Printf("  %s  %s  %-12s  %-18s  %4dms  %dm/%dr  %s\n",
+			status, local, e.ID, project, e.LatencyMS, e.MessageCount, regSize, result)
 	}
 
-	fmt.Printf("\n  %s\n", p)
+	if trivial > 0 {
+		fmt.Printf("\n  %d of %d skipped as trivial messages (no LLM call)\n", trivial, len(entries))
+	}
+	fmt.Printf("\n  %s\n", storeLocation(cfg))
 	return nil
 }
 
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/markmdev/reflex/internal"
+)
+
+func runPrompt(args []string) error {
+	if len(args) == 0 || args[0] != "render" {
+		return fmt.Errorf("usage: reflex prompt render [--config path] < input.json")
+	}
+	configPath := ""
+	for i, arg := range args {
+		if arg == "--config" && i+1 < len(args) {
+			configPath = args[i+1]
+		}
+	}
+
+	cfg, err := internal.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	var input internal.RouteInput
+	if err := json.NewDecoder(os.Stdin).Decode(&input); err != nil {
+		return fmt.Errorf("invalid input: %w", err)
+	}
+
+	// Include the session Reflex tracks itself, as `reflex route` would
+	if input.SessionKey != "" {
+		store, err := internal.OpenStore(cfg)
+		if err != nil {
+			return err
+		}
+		saved, err := store.LoadSession(input.SessionKey)
+		store.Close()
+		if err != nil {
+			return err
+		}
+		input.Session = internal.MergeSessions(input.Session, saved)
+	}
+
+	cwd, _ := os.Getwd()
+	prompt, info, err := (&internal.Router{Config: cfg, Root: cwd}).RenderPrompt(input)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "[reflex] prompt_version: %s\n", info.PromptVersion)
+	for _, w := range info.Warnings {
+		fmt.Fprintf(os.Stderr, "[reflex] warning: %s\n", w)
+	}
+	fmt.Print(prompt)
+	return nil
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/markmdev/reflex/internal"
+)
+
+func runReplay(args []string) error {
+	var model, configPath string
+	var since time.Time
+	limit := 0
+	asJSON := false
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--model":
+			if i+1 < len(args) {
+				model = args[i+1]
+				i++
+			}
+		case "--since":
+			if i+1 < len(args) {
+				t, err := parseSince(args[i+1])
+				if err != nil {
+					return err
+				}
+				since = t
+				i++
+			}
+		case "--limit":
+			if i+1 < len(args) {
+				n, err := strconv.Atoi(args[i+1])
+				if err != nil || n < 1 {
+					return fmt.Errorf("invalid --limit: %s", args[i+1])
+				}
+				limit = n
+				i++
+			}
+		case "--json":
+			asJSON = true
+		case "--config":
+			if i+1 < len(args) {
+				configPath = args[i+1]
+				i++
+			}
+		default:
+			return fmt.Errorf("usage: reflex replay [--model X] [--since 7d] [--limit N] [--json] [--config path]")
+		}
+	}
+
+	cfg, err := internal.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	store, err := internal.OpenStore(cfg)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	entries, err := store.ReadLog(internal.LogQuery{Since: since, Limit: limit})
+	if err != nil {
+		return err
+	}
+
+	if model != "" {
+		cfg.Provider.Model = model
+	}
+	// No store: replayed decisions must come from the model, not the cache
+	report := internal.Replay(entries, &internal.Router{Config: cfg})
+
+	if asJSON {
+		out, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(out))
+		return nil
+	}
+	if report.Replayed == 0 {
+		fmt.Println("No recorded inputs to replay.")
+		if report.NoInput > 0 {
+			fmt.Printf("%d entries were logged without their input. Enable it with log.record_input: true\n", report.NoInput)
+		}
+		return nil
+	}
+	printReplayReport(report)
+	return nil
+}
+
+func printReplayReport(r internal.ReplayReport) {
+	for _, c := range r.Cases {
+		if !c.Changed() && c.Error == "" {
+			continue
+		}
+		t, _ := time.Parse(time.RFC3339, c.Timestamp)
+		fmt.Printf("  %s  %s\n", t.Local().Format("01-02 15:04"), truncate(c.Message, 60))
+		if c.Error != "" {
+			fmt.Printf("       error: %s\n", truncate(c.Error, 80))
+			continue
+		}
+		fmt.Printf("       before: %s\n", listOrEmpty(c.Before))
+		fmt.Printf("       after:  %s\n", listOrEmpty(c.After))
+	}
+
+	fmt.Printf("\n%d decisions replayed against %s (%dms)\n", r.Replayed, r.Model, r.LatencyMS)
+	fmt.Printf("  changed:   %d\n", r.Changed)
+	fmt.Printf("  unchanged: %d\n", r.Replayed-r.Changed-r.Errors)
+	if r.Errors > 0 {
+		fmt.Printf("  errors:    %d\n", r.Errors)
+	}
+	if r.NoInput > 0 {
+		fmt.Printf("  %d entries skipped: logged without log.record_input\n", r.NoInput)
+	}
+}
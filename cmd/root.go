@@ -9,13 +9,38 @@ const usage = `Usage: reflex <command>
 
 Commands:
   route              Route a conversation to relevant docs and skills
+  discover [dir]     Print the registry of docs, doc sections, and skills in a project
+  lint [dir]         Check doc and skill frontmatter for problems
+  annotate [paths]   Propose summary and read_when for docs that lack them
+  hints suggest      Propose read_when hints mined from routing history
+  feedback <id>      Label a logged decision --good, --bad [items], or --missed <items>
+  feedback export    Write labeled decisions as an eval dataset (JSONL)
   logs               Show recent routing decisions
   config show        Show current configuration
-  config set <k> <v> Set a config value (api-key, model, base-url, max-tokens)
+  config set <k> <v> Set a config value (api-key, model, base-url, storage)
   config reset       Reset global config to defaults
+  cache stats        Show decision cache size and hit counts
+  cache clear        Delete all cached routing decisions
+  stats              Summarize routing history (statuses, latency, top items)
+  session list       List tracked sessions
+  session show <key> Show what a session has already been given
+  session clear <key> Forget a session's injection history
+  storage migrate    Copy JSONL logs, state files, and cache into SQLite
+  eval <dataset>     Score routing against a labeled YAML/JSONL dataset
+  prompt render      Print the exact routing prompt for a stdin route input
+  replay             Re-route recorded inputs and show decisions that changed
 
 Flags:
   logs --last N      Show last N entries (default: 20)
+  stats --since 7d   Only include decisions from the last 7 days (or 12h, 2026-03-01)
+  storage migrate --db <path>  Migrate into this database file
+  lint --json        Print issues as JSON; --strict also fails on warnings
+  annotate --dry-run Print proposed frontmatter as diffs; --yes writes without asking
+  hints suggest --since 30d  Only mine recent decisions (with --min-count N, --all, --json)
+  eval --mock        Serve each case's recorded response instead of calling the model
+  eval --json        Print the full report as JSON
+  eval --min-f1 X    Exit non-zero if aggregate F1 is below X (for CI)
+  replay --model X   Replay against model X (with --since 7d, --limit N, --json)
 `
 
 func Execute() error {
@@ -28,10 +53,34 @@ func Execute() error {
 	switch args[0] {
 	case "route":
 		return runRoute(args[1:])
+	case "discover":
+		return runDiscover(args[1:])
+	case "lint":
+		return runLint(args[1:])
+	case "annotate":
+		return runAnnotate(args[1:])
+	case "hints":
+		return runHints(args[1:])
+	case "feedback":
+		return runFeedback(args[1:])
 	case "config":
 		return runConfig(args[1:])
 	case "logs":
 		return runLogs(args[1:])
+	case "cache":
+		return runCache(args[1:])
+	case "stats":
+		return runStats(args[1:])
+	case "session":
+		return runSession(args[1:])
+	case "storage":
+		return runStorage(args[1:])
+	case "eval":
+		return runEval(args[1:])
+	case "prompt":
+		return runPrompt(args[1:])
+	case "replay":
+		return runReplay(args[1:])
 	default:
 		return fmt.Errorf("unknown command: %s\n\n%s", args[0], usage)
 	}
@@ -10,12 +10,16 @@ import (
 )
 
 func runRoute(args []string) error {
-	// Parse --config flag if present
+	// Parse --config and --inline flags if present
 	configPath := ""
+	inline := false
 	for i, arg := range args {
 		if arg == "--config" && i+1 < len(args) {
 			configPath = args[i+1]
 		}
+		if arg == "--inline" {
+			inline = true
+		}
 	}
 
 	// Load config
@@ -25,12 +29,20 @@ func runRoute(args []string) error {
 		cfg = internal.DefaultConfig()
 	}
 
+	store, err := internal.OpenStore(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[reflex] storage error, falling back to jsonl: %v\n", err)
+		cfg.Storage.Backend = "jsonl"
+		store, _ = internal.OpenStore(cfg)
+	}
+	defer store.Close()
+
 	// Read input from stdin
 	var input internal.RouteInput
 	if err := json.NewDecoder(os.Stdin).Decode(&input); err != nil {
 		fmt.Fprintf(os.Stderr, "[reflex] invalid input: %v\n", err)
 		cwd, _ := os.Getwd()
-		internal.AppendLog(internal.LogEntry{
+		store.AppendLog(internal.LogEntry{
 			CWD:    cwd,
 			Status: "error",
 			Error:  "invalid stdin: " + err.Error(),
@@ -40,9 +52,29 @@ func runRoute(args []string) error {
 		return nil
 	}
 
+	// Merge session state tracked by Reflex itself (callers that pass session_key)
+	if input.SessionKey != "" {
+		saved, err := store.LoadSession(input.SessionKey)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[reflex] session error: %v\n", err)
+		}
+		input.Session = internal.MergeSessions(input.Session, saved)
+	}
+
+	// Hooks run us from the project root, which registry paths and
+	// metadata.files are relative to.
+	cwd, _ := os.Getwd()
+
+	// Check whether the agent read the docs it was given last turn
+	var compliance *internal.Compliance
+	if input.SessionKey != "" && input.Transcript != "" {
+		compliance, input.Session = internal.CheckCompliance(input.Session, input.Transcript, cwd)
+	}
+
 	// Route
 	start := time.Now()
-	result, _, _, rawResponse, skipReason, routeErr := internal.Route(input, cfg)
+	router := &internal.Router{Config: cfg, Store: store, Root: cwd}
+	result, info, routeErr := router.Route(input)
 	latency := time.Since(start).Milliseconds()
 
 	status := "ok"
@@ -51,27 +83,58 @@ func runRoute(args []string) error {
 		fmt.Fprintf(os.Stderr, "[reflex] routing error: %v\n", routeErr)
 		errStr = routeErr.Error()
 		status = "error"
-		result = &internal.RouteResult{Docs: []string{}, Skills: []string{}}
-	} else if skipReason != "" {
+	} else if info.SkipReason != "" && len(result.Items) > 0 {
+		status = "pinned"
+	} else if info.SkipReason != "" {
 		status = "skipped"
+	} else if info.Cached {
+		status = "cached"
 	}
 
 	// Log
-	cwd, _ := os.Getwd()
 	session := input.Session
-	internal.AppendLog(internal.LogEntry{
-		CWD:          cwd,
-		Status:       status,
-		SkipReason:   skipReason,
-		MessageCount: len(input.Messages),
-		Registry:     input.Registry,
-		Session:      &session,
-		RawResponse:  rawResponse,
-		Result:       result,
-		LatencyMS:    latency,
-		Model:        cfg.Provider.Model,
-		Error:        errStr,
-	})
+	entry := internal.LogEntry{
+		ID:            internal.NewLogID(),
+		CWD:           cwd,
+		Status:        status,
+		SkipReason:    info.SkipReason,
+		MessageCount:  len(input.Messages),
+		Registry:      input.Registry,
+		Session:       &session,
+		SessionKey:    input.SessionKey,
+		RawResponse:   info.RawResponse,
+		Result:        result,
+		LatencyMS:     latency,
+		Model:         cfg.Provider.Model,
+		PromptVersion: info.PromptVersion,
+		Error:         errStr,
+		Redactions:    info.Redactions,
+		Dropped:       info.Dropped,
+		Requires:      info.Requires,
+		Warnings:      info.Warnings,
+		Compliance:    compliance,
+	}
+	if cfg.Log.RecordInput {
+		entry.Input = &input
+	}
+	internal.NewRedactor(cfg.Redaction).RedactLogEntry(&entry)
+	store.AppendLog(entry)
+	result.LogID = entry.ID
+	result.SessionKey = input.SessionKey
+
+	// Inline doc content for the caller only; it was not logged above.
+	// Doc paths are relative to the project root, which hooks run us from.
+	if inline || cfg.Inline.Enabled {
+		internal.InlineDocs(result, input.Registry, input.Messages, cwd, cfg.Inline)
+	}
+
+	// Save session state. Inlined docs need no read, so they count as read at once.
+	if input.SessionKey != "" && (len(result.Items) > 0 || compliance != nil) {
+		state := internal.RecordInjection(input.Session, result)
+		if err := store.SaveSession(input.SessionKey, state); err != nil {
+			fmt.Fprintf(os.Stderr, "[reflex] session error: %v\n", err)
+		}
+	}
 
 	// Output
 	out, _ := json.Marshal(result)
@@ -80,5 +143,5 @@ func runRoute(args []string) error {
 }
 
 func printEmpty() {
-	fmt.Println(`{"docs":[],"skills":[]}`)
+	fmt.Println(`{"docs":[],"skills":[],"items":[]}`)
 }
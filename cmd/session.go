@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+func runSession(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: reflex session <list|show|clear> [key]")
+	}
+	switch args[0] {
+	case "list":
+		return sessionList()
+	case "show", "clear":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: reflex session %s <key>", args[0])
+		}
+		if args[0] == "show" {
+			return sessionShow(args[1])
+		}
+		return sessionClear(args[1])
+	default:
+		return fmt.Errorf("unknown session command: %s\n\nCommands: list, show, clear", args[0])
+	}
+}
+
+func sessionList() error {
+	_, store, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	sessions, err := store.ListSessions()
+	if err != nil {
+		return err
+	}
+	if len(sessions) == 0 {
+		fmt.Println("No sessions.")
+		return nil
+	}
+	for _, s := range sessions {
+		fmt.Printf("  %s  %-40s  %d docs, %d skills\n",
+			s.Updated.Local().Format("2006-01-02 15:04"), s.Key, len(s.State.DocsRead), len(s.State.SkillsUsed))
+	}
+	return nil
+}
+
+func sessionShow(key string) error {
+	_, store, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	state, err := store.LoadSession(key)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Session %s\n", key)
+	fmt.Printf("  docs read:   %s\n", orNone(state.DocsRead))
+	fmt.Printf("  skills used: %s\n", orNone(state.SkillsUsed))
+	if len(state.ItemsUsed) > 0 {
+		fmt.Printf("  other items: %s\n", orNone(state.ItemsUsed))
+	}
+	if len(state.Pending) > 0 || len(state.Ignored) > 0 {
+		fmt.Printf("  not yet read: %s\n", orNone(state.Pending))
+		fmt.Printf("  ignored:      %s\n", orNone(state.Ignored))
+	}
+	return nil
+}
+
+func sessionClear(key string) error {
+	_, store, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	if err := store.DeleteSession(key); err != nil {
+		return fmt.Errorf("failed to clear session: %w", err)
+	}
+	fmt.Printf("Cleared session %s\n", key)
+	return nil
+}
+
+func orNone(items []string) string {
+	if len(items) == 0 {
+		return "(none)"
+	}
+	return strings.Join(items, ", ")
+}
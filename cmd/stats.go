@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/markmdev/reflex/internal"
+)
+
+func runStats(args []string) error {
+	var since time.Time
+	for i, arg := range args {
+		if arg == "--since" && i+1 < len(args) {
+			t, err := parseSince(args[i+1])
+			if err != nil {
+				return err
+			}
+			since = t
+		}
+	}
+
+	cfg, store, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	entries, err := store.ReadLog(internal.LogQuery{Since: since})
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Println("No logs yet.")
+		return nil
+	}
+
+	statuses := map[string]int{}
+	models := map[string]int{}
+	docs := map[string]int{}
+	skills := map[string]int{}
+	ignored := map[string]int{}
+	trivial, read, checked := 0, 0, 0
+	var latencies []int64
+	for _, e := range entries {
+		statuses[e.Status]++
+		models[e.Model]++
+		if internal.IsTrivialSkip(e.SkipReason) {
+			trivial++
+		}
+		if e.Status == "ok" {
+			latencies = append(latencies, e.LatencyMS)
+		}
+		if c := e.Compliance; c != nil {
+			read += len(c.Read)
+			checked += len(c.Read) + len(c.Ignored)
+			for _, d := range c.Ignored {
+				ignored[d]++
+			}
+		}
+		if e.Result != nil {
+			for _, d := range e.Result.Docs {
+				docs[d]++
+			}
+			for _, s := range e.Result.Skills {
+				skills[s]++
+			}
+		}
+	}
+
+	first, _ := time.Parse(time.RFC3339, entries[0].Timestamp)
+	last, _ := time.Parse(time.RFC3339, entries[len(entries)-1].Timestamp)
+	fmt.Printf("Routing decisions: %d  (%s → %s)\n", len(entries),
+		first.Local().Format("2006-01-02"), last.Local().Format("2006-01-02"))
+	fmt.Printf("  ok:      %d\n", statuses["ok"])
+	fmt.Printf("  cached:  %d\n", statuses["cached"])
+	fmt.Printf("  pinned:  %d (model skipped, pinned items injected)\n", statuses["pinned"])
+	fmt.Printf("  skipped: %d (%d trivial messages)\n", statuses["skipped"], trivial)
+	fmt.Printf("  error:   %d\n", statuses["error"])
+
+	if len(latencies) > 0 {
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		var sum int64
+		for _, l := range latencies {
+			sum += l
+		}
+		fmt.Printf("\nLLM latency: avg %dms  p50 %dms  p95 %dms\n",
+			sum/int64(len(latencies)), percentile(latencies, 50), percentile(latencies, 95))
+	}
+
+	printTop("Models", models, 5, "")
+	printTop("Top docs", docs, 10, "")
+	printTop("Top skills", skills, 10, "/")
+
+	if checked > 0 {
+		fmt.Printf("\nRead compliance: %d of %d injected docs read (%d%%)\n", read, checked, read*100/checked)
+		printTop("Most ignored docs", ignored, 5, "")
+	}
+
+	fmt.Printf("\n  %s\n", storeLocation(cfg))
+	return nil
+}
+
+// parseSince accepts a duration like "7d", "12h", "30m" or a date like "2026-03-01".
+func parseSince(s string) (time.Time, error) {
+	if strings.HasSuffix(s, "d") {
+		if days, err := strconv.Atoi(strings.TrimSuffix(s, "d")); err == nil {
+			return time.Now().AddDate(0, 0, -days), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since value %q (use e.g. 7d, 12h, or 2026-03-01)", s)
+}
+
+func percentile(sorted []int64, p int) int64 {
+	idx := (len(sorted) - 1) * p / 100
+	return sorted[idx]
+}
+
+func printTop(title string, counts map[string]int, n int, prefix string) {
+	if len(counts) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > n {
+		keys = keys[:n]
+	}
+	fmt.Printf("\n%s:\n", title)
+	for _, k := range keys {
+		fmt.Printf("  %5d  %s%s\n", counts[k], prefix, k)
+	}
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/markmdev/reflex/internal"
+)
+
+// openStore loads config and opens the configured storage backend.
+func openStore() (*internal.Config, internal.Store, error) {
+	cfg, err := internal.LoadConfig("")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	store, err := internal.OpenStore(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfg, store, nil
+}
+
+// storeLocation describes where the configured backend keeps logs.
+func storeLocation(cfg *internal.Config) string {
+	if cfg.Storage.Backend == "sqlite" {
+		if cfg.Storage.Path != "" {
+			return cfg.Storage.Path
+		}
+		return internal.DefaultDBPath()
+	}
+	return internal.LogPath()
+}
+
+func runStorage(args []string) error {
+	if len(args) == 0 || args[0] != "migrate" {
+		return fmt.Errorf("usage: reflex storage migrate [--db <path>]")
+	}
+
+	cfg, err := internal.LoadConfig("")
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	path := cfg.Storage.Path
+	if path == "" {
+		path = internal.DefaultDBPath()
+	}
+	for i, arg := range args {
+		if arg == "--db" && i+1 < len(args) {
+			path = args[i+1]
+		}
+	}
+
+	res, err := internal.MigrateToSQLite(cfg, path)
+	if err != nil {
+		return fmt.Errorf("migration failed: %w", err)
+	}
+	fmt.Printf("Migrated %d log entries, %d feedback labels, %d sessions, %d cached decisions into %s\n", res.Logs, res.Feedback, res.Sessions, res.Cache, path)
+	if res.Existing > 0 {
+		fmt.Printf("Skipped %d log entries and feedback labels already in the database\n", res.Existing)
+	}
+	if cfg.Storage.Backend != "sqlite" {
+		fmt.Println("\nSwitch to it with: reflex config set storage sqlite")
+	}
+	return nil
+}
@@ -12,6 +12,16 @@ import path from "node:path";
 import { spawnSync } from "node:child_process";
 
 const LOOKBACK = 10;
+// Items scored below this are offered as suggestions rather than instructions
+const WEAK_SCORE = 0.7;
+// How to present selected registry items of kinds other than doc and skill
+const KIND_TEXT = {
+  command: "These slash commands fit this task:",
+  mcp_tool: "These MCP tools are relevant to this task:",
+  subagent: "Consider delegating to these subagents:",
+  rule: "Follow the rules in these files; read them now:",
+  memory: "Read these memory files for context on this part of the project:",
+};
 
 const SKIP_DIRS = new Set([
   ".git", "node_modules", ".next", "dist", "build", "__pycache__",
@@ -187,10 +197,35 @@ function findReflexBin() {
   return "reflex";
 }
 
-function callReflex(payload) {
+/** Run `reflex discover` in the workspace. Returns null if the binary is missing, old, or fails. */
+function discoverViaReflex(workspaceDir) {
+  try {
+    const r = spawnSync(findReflexBin(), ["discover"], { cwd: workspaceDir, encoding: "utf-8", timeout: 5000 });
+    if (r.status !== 0) return null;
+    const registry = JSON.parse(r.stdout.trim());
+    return registry && typeof registry === "object" ? registry : null;
+  } catch {
+    return null;
+  }
+}
+
+/** Current git branch of the workspace, or "" outside a repo or on a detached HEAD. */
+function gitBranch(workspaceDir) {
+  try {
+    const r = spawnSync("git", ["rev-parse", "--abbrev-ref", "HEAD"], { cwd: workspaceDir, encoding: "utf-8", timeout: 2000 });
+    const branch = r.status === 0 ? r.stdout.trim() : "";
+    return branch === "HEAD" ? "" : branch;
+  } catch {
+    return "";
+  }
+}
+
+function callReflex(payload, workspaceDir) {
   const bin = findReflexBin();
   try {
+    // Run from the workspace so its .reflex/config.yaml applies
     const r = spawnSync(bin, ["route"], {
+      cwd: workspaceDir,
       input: JSON.stringify(payload),
       encoding: "utf-8",
       timeout: 15000,
@@ -236,10 +271,16 @@ export default {
 
       const sessionKey = ctx.sessionKey ?? "default";
 
-      // Discover registry — bail early if nothing to route
-      const docs = discoverDocs(workspaceDir);
-      const skills = discoverSkills(workspaceDir);
-      if (!docs.length && !skills.length) return;
+      // Discover registry — bail early if nothing to route. `reflex discover` also
+      // splits large docs into sections; older binaries fall back to the local scan.
+      const registry = discoverViaReflex(workspaceDir) ?? {
+        docs: discoverDocs(workspaceDir),
+        skills: discoverSkills(workspaceDir),
+      };
+      const docs = registry.docs ?? [];
+      const skills = registry.skills ?? [];
+      const items = registry.items ?? [];
+      if (!docs.length && !skills.length && !items.length) return;
 
       // event.messages has the conversation history directly — no file reading needed
       const messages = extractMessages(event.messages, LOOKBACK);
@@ -248,35 +289,70 @@ export default {
         messages.push({ type: "user", text: event.prompt.trim() });
       }
 
-      // Route
+      // Route. The branch is shown to the router and matched by `when` conditions.
       const sessionState = loadSessionState(sessionKey);
+      const branch = gitBranch(workspaceDir);
       const result = callReflex({
         messages,
-        registry: { docs, skills },
+        registry: { docs, skills, items },
         session: sessionState,
-        metadata: {},
-      });
+        metadata: branch ? { branch } : {},
+      }, workspaceDir);
 
       const newDocs = result.docs ?? [];
       const newSkills = result.skills ?? [];
-      if (!newDocs.length && !newSkills.length) return;
+      // Commands, MCP tools, subagents, rules, and memory files (older binaries return no by_kind)
+      const others = Object.entries(result.by_kind ?? {}).filter(([k, ids]) => k !== "doc" && k !== "skill" && ids?.length);
+      if (!newDocs.length && !newSkills.length && !others.length) return;
 
       // Persist injected items to avoid repeating across turns
       sessionState.docs_read = [...new Set([...sessionState.docs_read, ...newDocs])];
       sessionState.skills_used = [...new Set([...sessionState.skills_used, ...newSkills])];
+      const newItems = others.flatMap(([k, ids]) => ids.map((id) => `${k}:${id}`));
+      sessionState.items_used = [...new Set([...(sessionState.items_used ?? []), ...newItems])];
       saveSessionState(sessionKey, sessionState);
 
+      // Weak matches read as suggestions (older binaries return no items)
+      const scores = new Map((result.items ?? []).map((i) => [`${i.kind}:${i.name}`, i.score ?? 1]));
+      const isWeak = (kind, name) => (scores.get(`${kind}:${name}`) ?? 1) < WEAK_SCORE;
+      // Docs whose code changed since they were last updated get a caution
+      const stale = new Set((result.items ?? []).filter((i) => i.kind === "doc" && i.stale).map((i) => i.name));
+      const mark = (d) => (stale.has(d) ? `${d} (may be outdated; verify against the code)` : d);
+      // Docs inlined by reflex (inline.enabled) are injected as content instead of a read instruction
+      const content = (result.content ?? []).filter((c) => newDocs.includes(c.path) && !isWeak("doc", c.path));
+      const inlined = new Set(content.map((c) => c.path));
+      const strongDocs = newDocs.filter((d) => !isWeak("doc", d) && !inlined.has(d));
+      const strongSkills = newSkills.filter((s) => !isWeak("skill", s));
+      const maybe = [
+        ...newDocs.filter((d) => isWeak("doc", d)).map((d) => `- ${mark(d)}`),
+        ...newSkills.filter((s) => isWeak("skill", s)).map((s) => `- /${s} skill`),
+      ];
+
       // Build injection — returned as prependContext, prepended to the user's prompt
       const parts = [];
-      if (newDocs.length) {
-        const docList = newDocs.map((d) => `- ${d}`).join("\n");
+      if (content.length) {
+        parts.push("Project docs relevant to this request:");
+        for (const c of content) {
+          const attrs = `${c.truncated ? ' excerpt="true"' : ""}${stale.has(c.path) ? ' stale="true"' : ""}`;
+          parts.push(`<doc path="${c.path}"${attrs}>\n${c.text ?? ""}\n</doc>`);
+        }
+      }
+      if (strongDocs.length) {
+        const docList = strongDocs.map((d) => `- ${mark(d)}`).join("\n");
         parts.push(
           `Before responding, read these files. Do not skip this even if you think ` +
           `you already know the content — read them now:\n${docList}`
         );
       }
-      if (newSkills.length) {
-        parts.push(`Use the ${newSkills.map((s) => "/" + s).join(", ")} skill for this task.`);
+      if (strongSkills.length) {
+        parts.push(`Use the ${strongSkills.map((s) => "/" + s).join(", ")} skill for this task.`);
+      }
+      for (const [kind, ids] of others) {
+        const names = ids.map((i) => (kind === "command" ? "/" + i : i)).join(", ");
+        parts.push(`${KIND_TEXT[kind] ?? `Also relevant (${kind}):`} ${names}`);
+      }
+      if (maybe.length) {
+        parts.push(`These may also be relevant; check them if the task touches their area:\n${maybe.join("\n")}`);
       }
 
       return { prependContext: parts.join("\n\n") };
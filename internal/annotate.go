@@ -0,0 +1,266 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// annotateMaxChars caps how much of a doc is sent to the model.
+const annotateMaxChars = 8000
+
+const annotatePrompt = `You are writing routing metadata for a project doc. An agent router will read the summary and read_when hints to decide whether an AI coding agent should read this doc before answering a request.
+
+Doc path: %s
+
+Doc content:
+%s
+
+Write:
+- summary: one sentence, under 100 characters, saying what the doc covers
+- read_when: 3 to 6 short phrases naming the tasks or topics a user's request would mention when this doc is needed. Be specific to this doc; avoid generic phrases like "code", "help", or "bug".
+
+Return ONLY valid JSON, no markdown fences:
+{"summary": "...", "read_when": ["...", "..."]}
+`
+
+// Annotation is proposed routing frontmatter for one doc.
+type Annotation struct {
+	Path     string   // relative to the project root
+	Summary  string   // empty when the doc already has one
+	ReadWhen []string // empty when the doc already has hints
+	Original string
+	Updated  string // Original with the proposed frontmatter added
+}
+
+// Annotator proposes summary and read_when frontmatter for docs using the
+// configured model.
+type Annotator struct {
+	Config   *Config
+	Provider Provider // nil: built from Config
+}
+
+// AnnotationCandidates returns the markdown files under root (or the given
+// files and directories) that lack a summary or read_when hints. Files with
+// invalid frontmatter are left for `reflex lint` to report.
+func AnnotationCandidates(root string, paths []string, cfg DiscoverConfig) ([]string, error) {
+	var out []string
+	check := func(rel string, data []byte) {
+		if needsAnnotation(string(data)) {
+			out = append(out, rel)
+		}
+	}
+	if len(paths) == 0 {
+		paths = []string{root}
+	}
+	for _, p := range paths {
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(root, p)
+		}
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			return nil, fmt.Errorf("%s is outside the project root %s", p, root)
+		}
+		if !info.IsDir() {
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return nil, err
+			}
+			check(filepath.ToSlash(rel), data)
+			continue
+		}
+		err = walkDocs(p, cfg, func(sub string, data []byte) {
+			check(filepath.ToSlash(filepath.Join(rel, sub)), data)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// needsAnnotation reports whether a doc is missing routing frontmatter and
+// can be given it. Unclosed or invalid frontmatter is left alone.
+func needsAnnotation(text string) bool {
+	front, _, ok := splitFrontmatter(text)
+	if !ok {
+		return !strings.HasPrefix(text, "---")
+	}
+	var fm map[string]any
+	if err := yaml.Unmarshal([]byte(front), &fm); err != nil {
+		return false
+	}
+	return fmString(fm, "summary") == "" || len(fmList(fm, "read_when")) == 0
+}
+
+// Propose asks the model for the doc's missing frontmatter. The doc is
+// redacted and truncated before it is sent.
+func (a *Annotator) Propose(rel, text string) (*Annotation, error) {
+	if a.Provider == nil {
+		p, err := NewProvider(a.Config)
+		if err != nil {
+			return nil, err
+		}
+		a.Provider = p
+	}
+
+	body := NewRedactor(a.Config.Redaction).Redact(stripFrontmatter(text), map[string]int{})
+	if len(body) > annotateMaxChars {
+		body = body[:annotateMaxChars] + "\n[truncated]"
+	}
+	raw, err := a.Provider.Complete(context.Background(), fmt.Sprintf(annotatePrompt, rel, body))
+	if err != nil {
+		return nil, err
+	}
+	var parsed struct {
+		Summary  string   `json:"summary"`
+		ReadWhen []string `json:"read_when"`
+	}
+	if err := json.Unmarshal([]byte(stripFences(raw)), &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse model response: %w", err)
+	}
+	var hints []string
+	for _, h := range parsed.ReadWhen {
+		if h = strings.TrimSpace(h); h != "" {
+			hints = append(hints, h)
+		}
+	}
+	parsed.Summary = strings.TrimSpace(parsed.Summary)
+	if parsed.Summary == "" || len(hints) == 0 {
+		return nil, fmt.Errorf("model returned no summary or read_when hints")
+	}
+
+	ann := &Annotation{Path: rel, Original: text}
+	fm := parseFrontmatter(text)
+	if fmString(fm, "summary") == "" {
+		ann.Summary = parsed.Summary
+	}
+	if len(fmList(fm, "read_when")) == 0 {
+		ann.ReadWhen = hints
+	}
+	ann.Updated = addFrontmatter(text, ann.Summary, ann.ReadWhen)
+	return ann, nil
+}
+
+// addFrontmatter adds summary and read_when keys to a doc, after any existing
+// frontmatter keys or in a new block at the top. Empty values are left out.
+// A key that is already present but empty is replaced in place, so the block
+// never ends up with the same key twice.
+func addFrontmatter(text, summary string, readWhen []string) string {
+	var keys yaml.Node
+	keys.Kind = yaml.MappingNode
+	if summary != "" {
+		keys.Content = append(keys.Content, scalarNode("summary"), scalarNode(summary))
+	}
+	if len(readWhen) > 0 {
+		list := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, h := range readWhen {
+			list.Content = append(list.Content, scalarNode(h))
+		}
+		keys.Content = append(keys.Content, scalarNode("read_when"), list)
+	}
+
+	front, body, ok := splitFrontmatter(text)
+	if !ok {
+		return "---\n" + encodeYAML(&keys) + "---\n" + text
+	}
+	if merged, ok := replaceEmptyKeys(front, &keys); ok {
+		return "---\n" + merged + "---\n" + body
+	}
+	if front != "" && !strings.HasSuffix(front, "\n") {
+		front += "\n"
+	}
+	return "---\n" + front + encodeYAML(&keys) + "---\n" + body
+}
+
+// replaceEmptyKeys sets the given keys in an existing frontmatter block that
+// already declares at least one of them, and returns the re-encoded block.
+// It reports false when none of the keys is present, so the caller can append
+// them and leave the original formatting untouched.
+func replaceEmptyKeys(front string, keys *yaml.Node) (string, bool) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(front), &doc); err != nil || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return "", false
+	}
+	m := doc.Content[0]
+	found := false
+	for i := 0; i+1 < len(keys.Content); i += 2 {
+		if j := mappingIndex(m, keys.Content[i].Value); j >= 0 {
+			m.Content[j+1] = keys.Content[i+1]
+			found = true
+		}
+	}
+	if !found {
+		return "", false
+	}
+	for i := 0; i+1 < len(keys.Content); i += 2 {
+		if mappingIndex(m, keys.Content[i].Value) < 0 {
+			m.Content = append(m.Content, keys.Content[i], keys.Content[i+1])
+		}
+	}
+	return encodeYAML(m), true
+}
+
+// mappingIndex returns the position of key in a mapping node's content, or -1.
+func mappingIndex(m *yaml.Node, key string) int {
+	for j := 0; j+1 < len(m.Content); j += 2 {
+		if m.Content[j].Value == key {
+			return j
+		}
+	}
+	return -1
+}
+
+func encodeYAML(n *yaml.Node) string {
+	var buf strings.Builder
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	enc.Encode(n)
+	enc.Close()
+	return buf.String()
+}
+
+func scalarNode(s string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: s}
+}
+
+// Diff returns the change as a unified-style diff of the lines that differ.
+func (a *Annotation) Diff() string {
+	old, updated := strings.Split(a.Original, "\n"), strings.Split(a.Updated, "\n")
+	pre := 0
+	for pre < len(old) && pre < len(updated) && old[pre] == updated[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(old)-pre && suf < len(updated)-pre && old[len(old)-1-suf] == updated[len(updated)-1-suf] {
+		suf++
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- a/%s\n+++ b/%s\n@@ -%d,%d +%d,%d @@\n", a.Path, a.Path, pre+1, len(old)-pre-suf, pre+1, len(updated)-pre-suf)
+	for _, l := range old[pre : len(old)-suf] {
+		b.WriteString("-" + l + "\n")
+	}
+	for _, l := range updated[pre : len(updated)-suf] {
+		b.WriteString("+" + l + "\n")
+	}
+	return b.String()
+}
+
+// Write saves the annotated doc under root.
+func (a *Annotation) Write(root string) error {
+	p := filepath.Join(root, filepath.FromSlash(a.Path))
+	info, err := os.Stat(p)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(p, []byte(a.Updated), info.Mode().Perm())
+}
@@ -0,0 +1,99 @@
+package internal
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestAnnotationCandidates(t *testing.T) {
+	root := t.TempDir()
+	writeDoc(t, root, "docs/done.md", "---\nsummary: Done\nread_when: [x]\n---\n")
+	writeDoc(t, root, "docs/plain.md", "# Plain\n")
+	writeDoc(t, root, "docs/half.md", "---\nowner: team-a\nread_when: [x]\n---\n")
+	writeDoc(t, root, "docs/broken.md", "---\nsummary: [oops\n---\n")
+	writeDoc(t, root, "notes/todo.md", "todo\n")
+
+	got, err := AnnotationCandidates(root, nil, DiscoverConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, ",") != "docs/half.md,docs/plain.md,notes/todo.md" {
+		t.Errorf("got %v", got)
+	}
+	got, _ = AnnotationCandidates(root, []string{"docs"}, DiscoverConfig{})
+	if strings.Join(got, ",") != "docs/half.md,docs/plain.md" {
+		t.Errorf("directory arg: got %v", got)
+	}
+	if _, err := AnnotationCandidates(root, []string{"../elsewhere"}, DiscoverConfig{}); err == nil {
+		t.Error("expected an error for a path outside the root")
+	}
+}
+
+func TestAnnotator_Propose(t *testing.T) {
+	cfg := DefaultConfig()
+	var prompt string
+	a := &Annotator{Config: cfg, Provider: promptSpy{&prompt, "```json\n{\"summary\": \"Billing: invoices: rounding\", \"read_when\": [\"invoice rounding\", \" \", \"currency\"]}\n```"}}
+
+	ann, err := a.Propose("docs/billing.md", "# Billing\n\nKey sk-abcdefghijklmnopqrstuvwxyz123456 rounds half up.\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(prompt, "sk-abcdef") || !strings.Contains(prompt, "docs/billing.md") {
+		t.Error("doc should be redacted and named in the prompt")
+	}
+	want := "---\nsummary: 'Billing: invoices: rounding'\nread_when:\n  - invoice rounding\n  - currency\n---\n# Billing\n"
+	if !strings.HasPrefix(ann.Updated, want) {
+		t.Errorf("updated doc:\n%s", ann.Updated)
+	}
+	if fm := parseFrontmatter(ann.Updated); fmString(fm, "summary") != "Billing: invoices: rounding" {
+		t.Errorf("frontmatter does not round-trip: %v", fm)
+	}
+	if d := ann.Diff(); !strings.Contains(d, "@@ -1,0 +1,6 @@\n+---\n+summary:") || strings.Contains(d, "-# Billing") {
+		t.Errorf("unexpected diff:\n%s", d)
+	}
+
+	// Existing keys are kept and only missing ones are added
+	ann, err = a.Propose("docs/half.md", "---\nowner: team-a\nread_when: [x]\n---\nbody\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ann.Updated != "---\nowner: team-a\nread_when: [x]\nsummary: 'Billing: invoices: rounding'\n---\nbody\n" || ann.ReadWhen != nil {
+		t.Errorf("unexpected update:\n%s", ann.Updated)
+	}
+
+	// Keys that are present but empty are replaced, not duplicated
+	ann, err = a.Propose("docs/empty.md", "---\nowner: team-a\nsummary: \"\"\nread_when: []\n---\nbody\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fm map[string]any
+	front, _, _ := splitFrontmatter(ann.Updated)
+	if err := yaml.Unmarshal([]byte(front), &fm); err != nil {
+		t.Fatalf("updated frontmatter does not parse: %v\n%s", err, ann.Updated)
+	}
+	if fm["owner"] != "team-a" || fm["summary"] != "Billing: invoices: rounding" || len(fmList(fm, "read_when")) != 2 {
+		t.Errorf("unexpected update:\n%s", ann.Updated)
+	}
+	if strings.Count(ann.Updated, "summary:") != 1 || strings.Count(ann.Updated, "read_when:") != 1 {
+		t.Errorf("keys should not be duplicated:\n%s", ann.Updated)
+	}
+
+	a.Provider = StaticProvider{Response: `{"summary": "", "read_when": []}`}
+	if _, err := a.Propose("docs/x.md", "x"); err == nil {
+		t.Error("expected an error for an empty proposal")
+	}
+}
+
+// promptSpy records the prompt it is given and returns a canned response.
+type promptSpy struct {
+	prompt   *string
+	response string
+}
+
+func (p promptSpy) Complete(_ context.Context, prompt string) (string, error) {
+	*p.prompt = prompt
+	return p.response, nil
+}
@@ -0,0 +1,213 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// cacheEntry is one cached routing decision, stored as <key>.json in CacheDir.
+type cacheEntry struct {
+	Created string      `json:"created"`
+	Model   string      `json:"model"`
+	Result  RouteResult `json:"result"`
+}
+
+// CacheStats summarizes the decision cache on disk.
+type CacheStats struct {
+	Entries int
+	Expired int
+	Bytes   int64
+	Oldest  time.Time
+	Newest  time.Time
+}
+
+// CacheDir returns ~/.config/reflex/cache.
+func CacheDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "reflex", "cache")
+}
+
+// cacheKeyVersion changes whenever the fields hashed by cacheKey do, so entries
+// keyed the old way are never served.
+const cacheKeyVersion = 2
+
+// cacheKey hashes what determines a routing decision: the latest user message(s),
+// the registry after session filtering, the agent context shown to the model,
+// the model, and the prompt version. Templates that can render the session or
+// raw metadata have those hashed too. Messages are normalized so retries with
+// different casing or punctuation still hit.
+func cacheKey(data PromptData, model string, tmpl *PromptTemplate, keyMessages int) string {
+	if keyMessages < 1 {
+		keyMessages = 1
+	}
+	var recent []string
+	for i := len(data.Messages) - 1; i >= 0 && len(recent) < keyMessages; i-- {
+		if data.Messages[i].Type == "user" {
+			recent = append(recent, normalizeMessage(data.Messages[i].Text))
+		}
+	}
+	key := struct {
+		Version  int            `json:"v"`
+		Messages []string       `json:"messages"`
+		Registry Registry       `json:"registry"`
+		Context  []MetaField    `json:"context,omitempty"`
+		Session  *SessionState  `json:"session,omitempty"`
+		Metadata map[string]any `json:"metadata,omitempty"`
+		Model    string         `json:"model"`
+		Prompt   string         `json:"prompt_version"`
+	}{cacheKeyVersion, recent, data.Registry, data.Context, nil, nil, model, tmpl.Version}
+	if tmpl.custom {
+		key.Session, key.Metadata = &data.Session, data.Metadata
+	}
+	b, _ := json.Marshal(key)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// cacheGet returns the cached result for key, or nil on a miss.
+// Expired entries are deleted on read.
+func cacheGet(cfg CacheConfig, key string) *RouteResult {
+	dir := CacheDir()
+	if cfg.Disabled || dir == "" {
+		return nil
+	}
+	p := filepath.Join(dir, key+".json")
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil
+	}
+	var e cacheEntry
+	if err := json.Unmarshal(data, &e); err != nil {
+		os.Remove(p)
+		return nil
+	}
+	if cacheExpired(e, cfg.ttl(), time.Now()) {
+		os.Remove(p)
+		return nil
+	}
+	return &e.Result
+}
+
+// cachePut stores result under key and evicts the oldest entries beyond MaxEntries.
+func cachePut(cfg CacheConfig, key, model string, result *RouteResult) {
+	dir := CacheDir()
+	if cfg.Disabled || dir == "" {
+		return
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return
+	}
+	data, _ := json.Marshal(cacheEntry{
+		Created: time.Now().UTC().Format(time.RFC3339),
+		Model:   model,
+		Result:  *result,
+	})
+
+	// Write to a temp file and rename so concurrent readers never see a partial entry
+	tmp, err := os.CreateTemp(dir, key+".*.tmp")
+	if err != nil {
+		return
+	}
+	_, werr := tmp.Write(data)
+	tmp.Close()
+	if werr != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, key+".json")); err != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+
+	evictCache(dir, cfg.MaxEntries)
+}
+
+// evictCache removes the oldest entries until at most max remain.
+func evictCache(dir string, max int) {
+	if max <= 0 {
+		return
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) <= max {
+		return
+	}
+	type aged struct {
+		path string
+		mod  time.Time
+	}
+	entries := make([]aged, 0, len(files))
+	for _, f := range files {
+		if info, err := os.Stat(f); err == nil {
+			entries = append(entries, aged{f, info.ModTime()})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].mod.Before(entries[j].mod) })
+	for _, e := range entries[:len(entries)-max] {
+		os.Remove(e.path)
+	}
+}
+
+func cacheExpired(e cacheEntry, ttl time.Duration, now time.Time) bool {
+	created, err := time.Parse(time.RFC3339, e.Created)
+	if err != nil {
+		return true
+	}
+	return ttl > 0 && now.Sub(created) > ttl
+}
+
+// ReadCacheStats scans the cache directory.
+func ReadCacheStats(cfg CacheConfig) (CacheStats, error) {
+	var stats CacheStats
+	files, err := filepath.Glob(filepath.Join(CacheDir(), "*.json"))
+	if err != nil {
+		return stats, err
+	}
+	now := time.Now()
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		var e cacheEntry
+		if err := json.Unmarshal(data, &e); err != nil {
+			continue
+		}
+		stats.Entries++
+		stats.Bytes += int64(len(data))
+		if cacheExpired(e, cfg.ttl(), now) {
+			stats.Expired++
+		}
+		created, _ := time.Parse(time.RFC3339, e.Created)
+		if stats.Oldest.IsZero() || created.Before(stats.Oldest) {
+			stats.Oldest = created
+		}
+		if created.After(stats.Newest) {
+			stats.Newest = created
+		}
+	}
+	return stats, nil
+}
+
+// ClearCache deletes every cached decision. Returns the number of entries removed.
+func ClearCache() (int, error) {
+	dir := CacheDir()
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, f := range files {
+		if err := os.Remove(f); err == nil {
+			n++
+		}
+	}
+	return n, nil
+}
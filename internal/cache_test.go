@@ -0,0 +1,144 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCacheKey_NormalizesMessages(t *testing.T) {
+	reg := Registry{Docs: []RegistryDoc{{Path: "docs/a.md", Summary: "a"}}}
+	a := cacheKey(PromptData{Messages: []Message{{Type: "user", Text: "How do I deploy?"}}, Registry: reg}, "m", defaultPrompt, 1)
+	b := cacheKey(PromptData{Messages: []Message{{Type: "user", Text: "how do i deploy"}}, Registry: reg}, "m", defaultPrompt, 1)
+	if a != b {
+		t.Error("keys should match for messages differing only in case and punctuation")
+	}
+}
+
+func TestCacheKey_DependsOnRegistryModelAndPrompt(t *testing.T) {
+	msgs := []Message{{Type: "user", Text: "deploy"}}
+	reg := Registry{Docs: []RegistryDoc{{Path: "docs/a.md", Summary: "a"}}}
+	data := PromptData{Messages: msgs, Registry: reg}
+	base := cacheKey(data, "m1", defaultPrompt, 1)
+
+	if base == cacheKey(data, "m2", defaultPrompt, 1) {
+		t.Error("key should change with the model")
+	}
+	other := Registry{Docs: []RegistryDoc{{Path: "docs/b.md", Summary: "b"}}}
+	if base == cacheKey(PromptData{Messages: msgs, Registry: other}, "m1", defaultPrompt, 1) {
+		t.Error("key should change with the registry")
+	}
+	if base == cacheKey(data, "m1", &PromptTemplate{Version: "v2"}, 1) {
+		t.Error("key should change with the prompt version")
+	}
+}
+
+func TestCacheKey_DependsOnWhatTheModelSees(t *testing.T) {
+	reg := Registry{Docs: []RegistryDoc{{Path: "docs/a.md", Summary: "a"}}}
+	data := PromptData{Messages: []Message{{Type: "user", Text: "deploy"}}, Registry: reg}
+	base := cacheKey(data, "m", defaultPrompt, 1)
+
+	onMain := data
+	onMain.Context = []MetaField{{Key: MetaBranch, Value: "main"}}
+	if base == cacheKey(onMain, "m", defaultPrompt, 1) {
+		t.Error("key should change with the agent context")
+	}
+
+	withSession := data
+	withSession.Session = SessionState{SkillsUsed: []string{"lint"}}
+	if base != cacheKey(withSession, "m", defaultPrompt, 1) {
+		t.Error("the built-in prompt doesn't render the session, so it shouldn't split the cache")
+	}
+	custom := &PromptTemplate{Version: defaultPrompt.Version, custom: true}
+	if cacheKey(data, "m", custom, 1) == cacheKey(withSession, "m", custom, 1) {
+		t.Error("custom templates may render the session, so it should be in the key")
+	}
+}
+
+func TestCache_PutGetAndExpiry(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg := CacheConfig{TTL: "1h", MaxEntries: 10}
+
+	result := &RouteResult{Reasoning: "r", Docs: []string{"docs/a.md"}, Skills: []string{}}
+	cachePut(cfg, "k1", "m", result)
+
+	got := cacheGet(cfg, "k1")
+	if got == nil || len(got.Docs) != 1 || got.Docs[0] != "docs/a.md" {
+		t.Fatalf("expected cached result, got %+v", got)
+	}
+	if cacheGet(cfg, "missing") != nil {
+		t.Error("expected miss for unknown key")
+	}
+
+	// Backdate the entry past its TTL
+	p := filepath.Join(CacheDir(), "k1.json")
+	old := `{"created":"` + time.Now().Add(-2*time.Hour).UTC().Format(time.RFC3339) + `","model":"m","result":{"reasoning":"","docs":[],"skills":[]}}`
+	if err := os.WriteFile(p, []byte(old), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if cacheGet(cfg, "k1") != nil {
+		t.Error("expired entry should be a miss")
+	}
+	if _, err := os.Stat(p); !os.IsNotExist(err) {
+		t.Error("expired entry should be deleted on read")
+	}
+}
+
+func TestCache_EvictsOldestBeyondMaxEntries(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg := CacheConfig{MaxEntries: 2}
+	result := &RouteResult{Docs: []string{}, Skills: []string{}}
+
+	for i, key := range []string{"a", "b", "c"} {
+		cachePut(cfg, key, "m", result)
+		// Distinct mtimes so eviction order is deterministic
+		ts := time.Now().Add(time.Duration(i-3) * time.Minute)
+		os.Chtimes(filepath.Join(CacheDir(), key+".json"), ts, ts)
+	}
+	// Trigger eviction again now that mtimes are spread out
+	cachePut(cfg, "c", "m", result)
+
+	stats, err := ReadCacheStats(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Entries != 2 {
+		t.Errorf("expected 2 entries after eviction, got %d", stats.Entries)
+	}
+	if cacheGet(cfg, "a") != nil {
+		t.Error("oldest entry should have been evicted")
+	}
+
+	n, err := ClearCache()
+	if err != nil || n != 2 {
+		t.Errorf("expected ClearCache to remove 2 entries, got %d (%v)", n, err)
+	}
+}
+
+func TestRoute_CacheHitSkipsLLM(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg := DefaultConfig()
+	input := RouteInput{
+		Messages: []Message{{Type: "user", Text: "how do I set up OAuth?"}},
+		Registry: Registry{
+			Docs:   []RegistryDoc{{Path: "docs/auth.md", Summary: "auth"}},
+			Skills: []RegistrySkill{},
+		},
+	}
+	key := cacheKey(PromptData{Messages: input.Messages, Registry: filterRegistry(input.Registry, input.Session)}, cfg.Provider.Model, defaultPrompt, cfg.Cache.KeyMessages)
+	cachePut(cfg.Cache, key, cfg.Provider.Model, &RouteResult{Docs: []string{"docs/auth.md"}, Skills: []string{}})
+
+	// No API key is configured, so anything but a cache hit would error
+	result, info, err := Route(input, cfg)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !info.Cached {
+		t.Error("expected info.Cached to be true")
+	}
+	if len(result.Docs) != 1 || result.Docs[0] != "docs/auth.md" {
+		t.Errorf("expected cached docs, got %v", result.Docs)
+	}
+}
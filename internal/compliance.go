@@ -0,0 +1,135 @@
+package internal
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+)
+
+// Compliance reports which of the docs injected on the previous turn the agent
+// read, judged by Read tool calls in its transcript.
+type Compliance struct {
+	Read    []string `json:"read,omitempty"`
+	Ignored []string `json:"ignored,omitempty"`
+}
+
+// CheckCompliance checks the session's pending docs against the Read tool calls
+// the agent made since the last user prompt in transcript. Read docs move to
+// DocsRead and leave the ignored list; unread ones join it. Pending is cleared
+// either way. Returns nil
+// when nothing was pending or the transcript can't be read.
+func CheckCompliance(session SessionState, transcript, root string) (*Compliance, SessionState) {
+	if len(session.Pending) == 0 {
+		return nil, session
+	}
+	reads, err := transcriptReads(transcript, root)
+	if err != nil {
+		return nil, session
+	}
+
+	c := &Compliance{}
+	for _, doc := range session.Pending {
+		file, _ := splitDocRef(doc)
+		if reads[file] {
+			c.Read = append(c.Read, doc)
+		} else {
+			c.Ignored = append(c.Ignored, doc)
+		}
+	}
+	session.Pending = nil
+	session.DocsRead = union(session.DocsRead, c.Read)
+	session.Ignored = union(without(session.Ignored, c.Read), c.Ignored)
+	return c, session
+}
+
+// reminders returns ignored docs still in the registry that haven't been
+// injected a second time yet. Each is injected again once.
+func reminders(registry Registry, session SessionState) []RankedItem {
+	reminded := make(map[string]bool, len(session.Reminded))
+	for _, d := range session.Reminded {
+		reminded[d] = true
+	}
+	ignored := make(map[string]bool, len(session.Ignored))
+	for _, d := range session.Ignored {
+		if !reminded[d] {
+			ignored[d] = true
+		}
+	}
+	var out []RankedItem
+	for _, d := range registry.Docs {
+		if ignored[d.Path] {
+			out = append(out, RankedItem{Kind: "doc", Name: d.Path, Score: 1, Reason: "injected earlier but not read", Reminder: true})
+		}
+	}
+	return out
+}
+
+// transcriptReads returns the files, relative to root, opened with the Read
+// tool after the last user prompt in a Claude Code transcript.
+func transcriptReads(path, root string) (map[string]bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reads := map[string]bool{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 1024*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var entry struct {
+			Type    string `json:"type"`
+			IsMeta  bool   `json:"isMeta"`
+			Message struct {
+				Content json.RawMessage `json:"content"`
+			} `json:"message"`
+		}
+		if json.Unmarshal(scanner.Bytes(), &entry) != nil {
+			continue
+		}
+		var text string
+		var blocks []struct {
+			Type  string `json:"type"`
+			Name  string `json:"name"`
+			Input struct {
+				FilePath string `json:"file_path"`
+			} `json:"input"`
+		}
+		switch {
+		case json.Unmarshal(entry.Message.Content, &text) == nil:
+		case json.Unmarshal(entry.Message.Content, &blocks) == nil:
+		default:
+			continue
+		}
+
+		switch entry.Type {
+		case "user":
+			if entry.IsMeta {
+				continue
+			}
+			prompt := text != ""
+			for _, b := range blocks {
+				if b.Type == "tool_result" {
+					prompt = false
+					break
+				}
+				if b.Type == "text" {
+					prompt = true
+				}
+			}
+			if prompt {
+				reads = map[string]bool{}
+			}
+		case "assistant":
+			for _, b := range blocks {
+				if b.Type != "tool_use" || b.Name != "Read" || b.Input.FilePath == "" {
+					continue
+				}
+				if rel := relPath(b.Input.FilePath, root); rel != "" {
+					reads[rel] = true
+				}
+			}
+		}
+	}
+	return reads, scanner.Err()
+}
@@ -0,0 +1,141 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeTranscript writes Claude Code transcript lines to a temp file.
+func writeTranscript(t *testing.T, lines ...string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(p, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func readCall(path string) string {
+	return `{"type":"assistant","message":{"role":"assistant","content":[{"type":"tool_use","name":"Read","input":{"file_path":"` + path + `"}}]}}`
+}
+
+func TestTranscriptReads_SinceLastPrompt(t *testing.T) {
+	root := "/proj"
+	p := writeTranscript(t,
+		`{"type":"user","message":{"role":"user","content":"first question"}}`,
+		readCall("/proj/docs/old.md"),
+		`{"type":"user","message":{"role":"user","content":"second question"}}`,
+		readCall("/proj/docs/auth.md"),
+		`{"type":"user","message":{"role":"user","content":[{"type":"tool_result","content":"..."}]}}`,
+		`{"type":"user","isMeta":true,"message":{"role":"user","content":"<system-reminder>x</system-reminder>"}}`,
+		`{"type":"assistant","message":{"role":"assistant","content":[{"type":"tool_use","name":"Edit","input":{"file_path":"/proj/docs/edit.md"}}]}}`,
+		readCall("/elsewhere/docs/x.md"),
+		readCall("src/main.go"),
+		`not json`,
+	)
+	reads, err := transcriptReads(p, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]bool{"docs/auth.md": true, "src/main.go": true}
+	if !reflect.DeepEqual(reads, want) {
+		t.Errorf("got %v, want %v", reads, want)
+	}
+}
+
+func TestCheckCompliance(t *testing.T) {
+	p := writeTranscript(t,
+		`{"type":"user","message":{"role":"user","content":[{"type":"text","text":"fix login"}]}}`,
+		readCall("/proj/docs/auth.md"),
+	)
+	session := SessionState{
+		DocsRead: []string{"docs/intro.md"},
+		Pending:  []string{"docs/auth.md#tokens", "docs/api.md"},
+		Ignored:  []string{"docs/db.md", "docs/auth.md#tokens"},
+	}
+
+	c, got := CheckCompliance(session, p, "/proj")
+	if c == nil {
+		t.Fatal("expected a compliance report")
+	}
+	if !reflect.DeepEqual(c.Read, []string{"docs/auth.md#tokens"}) || !reflect.DeepEqual(c.Ignored, []string{"docs/api.md"}) {
+		t.Errorf("unexpected report: %+v", c)
+	}
+	if len(got.Pending) != 0 {
+		t.Errorf("pending should be cleared, got %v", got.Pending)
+	}
+	if !reflect.DeepEqual(got.DocsRead, []string{"docs/intro.md", "docs/auth.md#tokens"}) {
+		t.Errorf("only the read doc should count as read, got %v", got.DocsRead)
+	}
+	if !reflect.DeepEqual(got.Ignored, []string{"docs/db.md", "docs/api.md"}) {
+		t.Errorf("unexpected ignored list: %v", got.Ignored)
+	}
+
+	if c, _ := CheckCompliance(SessionState{}, p, "/proj"); c != nil {
+		t.Errorf("nothing pending should give no report, got %+v", c)
+	}
+	if c, _ := CheckCompliance(session, filepath.Join(t.TempDir(), "missing.jsonl"), "/proj"); c != nil {
+		t.Errorf("unreadable transcript should give no report, got %+v", c)
+	}
+}
+
+func TestRecordInjection_PendingUntilRead(t *testing.T) {
+	result := &RouteResult{
+		Docs:    []string{"docs/api.md", "docs/inline.md"},
+		Items:   []RankedItem{{Kind: "doc", Name: "docs/api.md", Reminder: true}, {Kind: "doc", Name: "docs/inline.md"}},
+		Content: []DocContent{{Path: "docs/inline.md"}},
+	}
+	got := RecordInjection(SessionState{}, result)
+	if !reflect.DeepEqual(got.Pending, []string{"docs/api.md"}) {
+		t.Errorf("injected docs should be pending, got %v", got.Pending)
+	}
+	if !reflect.DeepEqual(got.DocsRead, []string{"docs/inline.md"}) {
+		t.Errorf("only inlined docs should count as read, got %v", got.DocsRead)
+	}
+	if !reflect.DeepEqual(got.Reminded, []string{"docs/api.md"}) {
+		t.Errorf("expected reminder recorded, got %v", got.Reminded)
+	}
+
+	// Pending docs are not offered again while the read is unconfirmed
+	registry := Registry{Docs: []RegistryDoc{{Path: "docs/api.md"}, {Path: "docs/inline.md"}, {Path: "docs/db.md"}}}
+	if rest := filterRegistry(registry, got); len(rest.Docs) != 1 || rest.Docs[0].Path != "docs/db.md" {
+		t.Errorf("given docs should be filtered, got %+v", rest.Docs)
+	}
+}
+
+func TestRoute_ReinjectsIgnoredDocsOnce(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg := DefaultConfig()
+	cfg.Compliance.Reinject = true
+	r := &Router{Config: cfg, Provider: StaticProvider{Response: `{"items":[{"kind":"doc","name":"docs/auth.md","score":0.9}]}`}}
+	registry := Registry{Docs: []RegistryDoc{
+		{Path: "docs/api.md", Summary: "api"},
+		{Path: "docs/auth.md", Summary: "auth"},
+		{Path: "docs/db.md", Summary: "db"},
+	}}
+	input := RouteInput{
+		Messages: []Message{{Type: "user", Text: "how does login work?"}},
+		Registry: registry,
+		Session:  SessionState{Ignored: []string{"docs/api.md", "docs/db.md"}, Reminded: []string{"docs/db.md"}},
+	}
+
+	result, _, err := r.Route(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(result.Docs, ","); got != "docs/api.md,docs/auth.md" {
+		t.Errorf("expected the ignored doc first, got %s", got)
+	}
+	if !result.Items[0].Reminder || result.Items[1].Reminder {
+		t.Errorf("only the re-injected doc should be a reminder: %+v", result.Items)
+	}
+
+	cfg.Compliance.Reinject = false
+	result, _, _ = r.Route(input)
+	if got := strings.Join(result.Docs, ","); got != "docs/auth.md" {
+		t.Errorf("reinject off should leave ignored docs out, got %s", got)
+	}
+}
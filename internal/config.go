@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -14,10 +16,208 @@ type ProviderConfig struct {
 	APIKey       string `yaml:"api_key,omitempty"`     // store key directly (set via `reflex config set`)
 	Model        string `yaml:"model"`
 	ResponsesAPI bool   `yaml:"responses_api,omitempty"` // use OpenAI Responses API instead of Chat Completions
+	Mode         string `yaml:"mode,omitempty"`          // "" (live), "record", or "playback"
+	FixtureDir   string `yaml:"fixture_dir,omitempty"`   // where record/playback keep fixtures
+}
+
+// fixtureDir returns the configured fixture directory or the default.
+func (c ProviderConfig) fixtureDir() string {
+	if c.FixtureDir != "" {
+		return c.FixtureDir
+	}
+	return DefaultFixtureDir()
+}
+
+// SkipConfig controls the trivial-message classifier that runs before the LLM call.
+type SkipConfig struct {
+	Disabled    bool     `yaml:"disabled,omitempty"`
+	MinLength   int      `yaml:"min_length,omitempty"`   // messages shorter than this (in characters) are skipped
+	StopPhrases []string `yaml:"stop_phrases,omitempty"` // added to the built-in acknowledgement list
+	Patterns    []string `yaml:"patterns,omitempty"`     // regexes; a match on the last user message skips routing
+
+	patterns []*regexp.Regexp // Patterns, compiled once by LoadConfig
+}
+
+// compilePatterns compiles Patterns, reporting invalid ones on stderr and
+// skipping them.
+func (c *SkipConfig) compilePatterns() {
+	c.patterns = nil
+	for _, p := range c.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[reflex] warning: invalid skip pattern %q: %v\n", p, err)
+			continue
+		}
+		c.patterns = append(c.patterns, re)
+	}
+}
+
+// CacheConfig controls the local decision cache.
+type CacheConfig struct {
+	Disabled    bool   `yaml:"disabled,omitempty"`
+	TTL         string `yaml:"ttl,omitempty"`          // Go duration, e.g. "24h"
+	MaxEntries  int    `yaml:"max_entries,omitempty"`  // oldest entries are evicted beyond this
+	KeyMessages int    `yaml:"key_messages,omitempty"` // how many recent user messages form the cache key
+}
+
+// ttl returns the parsed TTL, falling back to 24h when unset or invalid.
+func (c CacheConfig) ttl() time.Duration {
+	if d, err := time.ParseDuration(c.TTL); err == nil {
+		return d
+	}
+	return 24 * time.Hour
+}
+
+// LogConfig controls rotation of ~/.config/reflex/log.jsonl.
+type LogConfig struct {
+	MaxSizeKB   int  `yaml:"max_size_kb,omitempty"`  // rotate once the live log exceeds this
+	KeepEntries int  `yaml:"keep_entries,omitempty"` // entries kept in the live log after rotation
+	MaxArchives int  `yaml:"max_archives,omitempty"` // compressed archives kept (log.1.jsonl.gz is newest)
+	RecordInput bool `yaml:"record_input,omitempty"` // store the full (redacted) route input so `reflex replay` can re-run it
+}
+
+// withDefaults fills unset fields with the built-in limits.
+func (c LogConfig) withDefaults() LogConfig {
+	if c.MaxSizeKB <= 0 {
+		c.MaxSizeKB = maxLogSize / 1024
+	}
+	if c.KeepEntries <= 0 {
+		c.KeepEntries = keepEntries
+	}
+	if c.MaxArchives == 0 {
+		c.MaxArchives = maxArchives
+	}
+	return c
+}
+
+// RedactionPattern is a named regex whose matches are replaced before text leaves the machine.
+type RedactionPattern struct {
+	Name    string `yaml:"name"`
+	Pattern string `yaml:"pattern"`
+}
+
+// RedactionConfig controls scrubbing of secrets and PII from prompts and logs.
+type RedactionConfig struct {
+	Disabled bool               `yaml:"disabled,omitempty"`
+	Patterns []RedactionPattern `yaml:"patterns,omitempty"` // added to the built-in detectors
+}
+
+// RankingConfig trims the model's ranked selections.
+type RankingConfig struct {
+	MinScore  float64 `yaml:"min_score,omitempty"`  // drop items scored below this (0–1)
+	MaxDocs   int     `yaml:"max_docs,omitempty"`   // keep at most this many docs (0: no cap)
+	MaxSkills int     `yaml:"max_skills,omitempty"` // keep at most this many skills (0: no cap)
+}
+
+// InlineConfig controls returning doc content alongside selected paths.
+type InlineConfig struct {
+	Enabled   bool `yaml:"enabled,omitempty"`
+	MaxTokens int  `yaml:"max_tokens,omitempty"` // total budget across all inlined docs (default 4000)
+}
+
+// maxTokens returns the configured budget or the default.
+func (c InlineConfig) maxTokens() int {
+	if c.MaxTokens > 0 {
+		return c.MaxTokens
+	}
+	return 4000
+}
+
+// DiscoverConfig controls how `reflex discover` scans a project.
+type DiscoverConfig struct {
+	MaxDepth        int      `yaml:"max_depth,omitempty"`         // directory depth scanned for docs (default: no limit)
+	SkipDirs        []string `yaml:"skip_dirs,omitempty"`         // added to the built-in list (node_modules, .git, ...)
+	SectionLevel    int      `yaml:"section_level,omitempty"`     // heading level docs are split at (default 2, i.e. ##)
+	SectionMinLines int      `yaml:"section_min_lines,omitempty"` // split docs at least this long (default 300; -1 only with sections: true)
+	SkipStale       bool     `yaml:"skip_stale,omitempty"`        // don't check docs against git history for staleness
+}
+
+func (c DiscoverConfig) sectionLevel() int {
+	if c.SectionLevel >= 1 && c.SectionLevel <= 6 {
+		return c.SectionLevel
+	}
+	return 2
+}
+
+func (c DiscoverConfig) sectionMinLines() int {
+	if c.SectionMinLines == 0 {
+		return 300
+	}
+	return max(c.SectionMinLines, 0)
+}
+
+// LintConfig tunes `reflex lint`.
+type LintConfig struct {
+	MaxRegistryTokens int `yaml:"max_registry_tokens,omitempty"` // warn when the registry is larger (default 8000)
+}
+
+func (c LintConfig) maxRegistryTokens() int {
+	if c.MaxRegistryTokens > 0 {
+		return c.MaxRegistryTokens
+	}
+	return 8000
+}
+
+// FilesConfig controls docs scoped to files via applies_to.
+type FilesConfig struct {
+	Mode string `yaml:"mode,omitempty"` // "include" (default), "boost", or "off"
+}
+
+// ScopesConfig controls how docs of nested packages (directories with their own
+// .reflex/) are treated, relative to the agent's metadata.cwd.
+type ScopesConfig struct {
+	Mode string `yaml:"mode,omitempty"` // "filter" (default), "boost", or "off"
+}
+
+// ComplianceConfig controls what happens when the agent doesn't read injected docs.
+type ComplianceConfig struct {
+	Reinject bool `yaml:"reinject,omitempty"` // inject an ignored doc once more, as a reminder
+}
+
+// PromptConfig selects the routing prompt template.
+type PromptConfig struct {
+	Template string `yaml:"template,omitempty"` // text/template file; relative paths resolve against the config file
+	Version  string `yaml:"version,omitempty"`  // recorded as prompt_version (default: a hash of the template)
+}
+
+// StorageConfig selects where logs, session state, and cached decisions live.
+type StorageConfig struct {
+	Backend string `yaml:"backend,omitempty"` // "jsonl" (default) or "sqlite"
+	Path    string `yaml:"path,omitempty"`    // SQLite database file (default ~/.config/reflex/reflex.db)
+}
+
+// dbPath returns the configured database path or the default.
+func (c StorageConfig) dbPath() string {
+	if c.Path != "" {
+		return c.Path
+	}
+	return DefaultDBPath()
+}
+
+// DefaultDBPath returns ~/.config/reflex/reflex.db.
+func DefaultDBPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "reflex", "reflex.db")
 }
 
 type Config struct {
-	Provider ProviderConfig `yaml:"provider"`
+	Provider   ProviderConfig   `yaml:"provider"`
+	Skip       SkipConfig       `yaml:"skip,omitempty"`
+	Cache      CacheConfig      `yaml:"cache,omitempty"`
+	Log        LogConfig        `yaml:"log,omitempty"`
+	Storage    StorageConfig    `yaml:"storage,omitempty"`
+	Redaction  RedactionConfig  `yaml:"redaction,omitempty"`
+	Prompt     PromptConfig     `yaml:"prompt,omitempty"`
+	Ranking    RankingConfig    `yaml:"ranking,omitempty"`
+	Inline     InlineConfig     `yaml:"inline,omitempty"`
+	Discover   DiscoverConfig   `yaml:"discover,omitempty"`
+	Files      FilesConfig      `yaml:"files,omitempty"`
+	Lint       LintConfig       `yaml:"lint,omitempty"`
+	Compliance ComplianceConfig `yaml:"compliance,omitempty"`
+	Scopes     ScopesConfig     `yaml:"scopes,omitempty"`
 }
 
 func DefaultConfig() *Config {
@@ -27,6 +227,14 @@ func DefaultConfig() *Config {
 			Model:        "gpt-5.2",
 			ResponsesAPI: true,
 		},
+		Skip: SkipConfig{
+			MinLength: 2,
+		},
+		Cache: CacheConfig{
+			TTL:         "24h",
+			MaxEntries:  1000,
+			KeyMessages: 1,
+		},
 	}
 }
 
@@ -39,7 +247,27 @@ func GlobalConfigPath() string {
 	return filepath.Join(home, ".config", "reflex", "config.yaml")
 }
 
-// LoadConfig loads config by merging: defaults → global (→ explicit path if provided).
+// ProjectConfigPath returns the nearest .reflex/config.yaml at or above the
+// working directory, or "" if there is none.
+func ProjectConfigPath() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	for {
+		p := filepath.Join(dir, ".reflex", "config.yaml")
+		if _, err := os.Stat(p); err == nil && p != GlobalConfigPath() {
+			return p
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// LoadConfig loads config by merging: defaults → global → project (→ explicit path if provided).
 func LoadConfig(configPath string) (*Config, error) {
 	cfg := DefaultConfig()
 
@@ -48,6 +276,11 @@ func LoadConfig(configPath string) (*Config, error) {
 		mergeConfig(cfg, p)
 	}
 
+	// Project config, checked into the repository
+	if p := ProjectConfigPath(); p != "" {
+		mergeProjectConfig(cfg, p)
+	}
+
 	// Explicit path override (e.g. --config flag)
 	if configPath != "" {
 		mergeConfig(cfg, configPath)
@@ -60,6 +293,7 @@ func LoadConfig(configPath string) (*Config, error) {
 	if cfg.Provider.Model == "" {
 		cfg.Provider.Model = "gpt-5.2"
 	}
+	cfg.Skip.compilePatterns()
 	return cfg, nil
 }
 
@@ -98,15 +332,50 @@ func SaveGlobalConfig(cfg *Config) error {
 
 // mergeConfig reads a YAML file and merges non-zero fields into cfg.
 func mergeConfig(cfg *Config, path string) {
+	if overlay := readConfig(path); overlay != nil {
+		applyConfig(cfg, overlay)
+	}
+}
+
+// mergeProjectConfig overlays a project config. Projects may not change where
+// conversations and API keys are sent, or turn off redaction, so those
+// settings are ignored. They may add redaction patterns.
+func mergeProjectConfig(cfg *Config, path string) {
+	overlay := readConfig(path)
+	if overlay == nil {
+		return
+	}
+	p := &overlay.Provider
+	if p.BaseURL != "" || p.APIKey != "" || p.APIKeyEnv != "" {
+		fmt.Fprintf(os.Stderr, "[reflex] warning: %s: provider base_url and API keys are only read from the global config\n", path)
+		p.BaseURL, p.APIKey, p.APIKeyEnv = "", "", ""
+	}
+	if overlay.Redaction.Disabled {
+		fmt.Fprintf(os.Stderr, "[reflex] warning: %s: redaction can only be disabled in the global config\n", path)
+		overlay.Redaction.Disabled = false
+	}
+	applyConfig(cfg, overlay)
+}
+
+// readConfig parses a config file and resolves its relative paths against the
+// file's directory. Returns nil if the file is missing or malformed.
+func readConfig(path string) *Config {
 	data, err := os.ReadFile(path)
 	if err != nil {
-		return // file not found or unreadable — skip silently
+		return nil // file not found or unreadable — skip silently
 	}
 	var overlay Config
 	if err := yaml.Unmarshal(data, &overlay); err != nil {
 		fmt.Fprintf(os.Stderr, "[reflex] warning: malformed config %s: %v\n", path, err)
-		return
+		return nil
+	}
+	if t := overlay.Prompt.Template; t != "" && !filepath.IsAbs(t) {
+		overlay.Prompt.Template = filepath.Join(filepath.Dir(path), t)
 	}
+	return &overlay
+}
+
+func applyConfig(cfg *Config, overlay *Config) {
 	if overlay.Provider.BaseURL != "" {
 		cfg.Provider.BaseURL = overlay.Provider.BaseURL
 	}
@@ -122,5 +391,98 @@ func mergeConfig(cfg *Config, path string) {
 	if overlay.Provider.ResponsesAPI {
 		cfg.Provider.ResponsesAPI = true
 	}
+	if overlay.Provider.Mode != "" {
+		cfg.Provider.Mode = overlay.Provider.Mode
+	}
+	if overlay.Provider.FixtureDir != "" {
+		cfg.Provider.FixtureDir = overlay.Provider.FixtureDir
+	}
+	if overlay.Skip.Disabled {
+		cfg.Skip.Disabled = true
+	}
+	if overlay.Skip.MinLength != 0 {
+		cfg.Skip.MinLength = overlay.Skip.MinLength
+	}
+	cfg.Skip.StopPhrases = append(cfg.Skip.StopPhrases, overlay.Skip.StopPhrases...)
+	cfg.Skip.Patterns = append(cfg.Skip.Patterns, overlay.Skip.Patterns...)
+	if overlay.Cache.Disabled {
+		cfg.Cache.Disabled = true
+	}
+	if overlay.Cache.TTL != "" {
+		cfg.Cache.TTL = overlay.Cache.TTL
+	}
+	if overlay.Cache.MaxEntries != 0 {
+		cfg.Cache.MaxEntries = overlay.Cache.MaxEntries
+	}
+	if overlay.Cache.KeyMessages != 0 {
+		cfg.Cache.KeyMessages = overlay.Cache.KeyMessages
+	}
+	if overlay.Log.MaxSizeKB != 0 {
+		cfg.Log.MaxSizeKB = overlay.Log.MaxSizeKB
+	}
+	if overlay.Log.KeepEntries != 0 {
+		cfg.Log.KeepEntries = overlay.Log.KeepEntries
+	}
+	if overlay.Log.MaxArchives != 0 {
+		cfg.Log.MaxArchives = overlay.Log.MaxArchives
+	}
+	if overlay.Log.RecordInput {
+		cfg.Log.RecordInput = true
+	}
+	if overlay.Storage.Backend != "" {
+		cfg.Storage.Backend = overlay.Storage.Backend
+	}
+	if overlay.Storage.Path != "" {
+		cfg.Storage.Path = overlay.Storage.Path
+	}
+	if overlay.Redaction.Disabled {
+		cfg.Redaction.Disabled = true
+	}
+	cfg.Redaction.Patterns = append(cfg.Redaction.Patterns, overlay.Redaction.Patterns...)
+	if overlay.Prompt.Template != "" {
+		cfg.Prompt.Template = overlay.Prompt.Template
+	}
+	if overlay.Prompt.Version != "" {
+		cfg.Prompt.Version = overlay.Prompt.Version
+	}
+	if overlay.Ranking.MinScore != 0 {
+		cfg.Ranking.MinScore = overlay.Ranking.MinScore
+	}
+	if overlay.Ranking.MaxDocs != 0 {
+		cfg.Ranking.MaxDocs = overlay.Ranking.MaxDocs
+	}
+	if overlay.Ranking.MaxSkills != 0 {
+		cfg.Ranking.MaxSkills = overlay.Ranking.MaxSkills
+	}
+	if overlay.Inline.Enabled {
+		cfg.Inline.Enabled = true
+	}
+	if overlay.Inline.MaxTokens != 0 {
+		cfg.Inline.MaxTokens = overlay.Inline.MaxTokens
+	}
+	if overlay.Discover.MaxDepth != 0 {
+		cfg.Discover.MaxDepth = overlay.Discover.MaxDepth
+	}
+	cfg.Discover.SkipDirs = append(cfg.Discover.SkipDirs, overlay.Discover.SkipDirs...)
+	if overlay.Discover.SectionLevel != 0 {
+		cfg.Discover.SectionLevel = overlay.Discover.SectionLevel
+	}
+	if overlay.Discover.SectionMinLines != 0 {
+		cfg.Discover.SectionMinLines = overlay.Discover.SectionMinLines
+	}
+	if overlay.Discover.SkipStale {
+		cfg.Discover.SkipStale = true
+	}
+	if overlay.Files.Mode != "" {
+		cfg.Files.Mode = overlay.Files.Mode
+	}
+	if overlay.Lint.MaxRegistryTokens != 0 {
+		cfg.Lint.MaxRegistryTokens = overlay.Lint.MaxRegistryTokens
+	}
+	if overlay.Compliance.Reinject {
+		cfg.Compliance.Reinject = true
+	}
+	if overlay.Scopes.Mode != "" {
+		cfg.Scopes.Mode = overlay.Scopes.Mode
+	}
 }
-
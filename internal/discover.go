@@ -0,0 +1,284 @@
+package internal
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// defaultSkipDirs are never scanned for docs.
+var defaultSkipDirs = []string{
+	".git", "node_modules", ".next", "dist", "build", "__pycache__",
+	".venv", "venv", ".tox", "coverage", ".turbo", "vendor", "target",
+}
+
+// skillDirs hold SKILL.md files, relative to the project root. They are
+// scanned for skills and excluded from docs.
+var skillDirs = []string{filepath.Join(".claude", "skills"), filepath.Join(".openclaw", "skills")}
+
+// sectionHintRe matches a per-section hint comment placed under a heading:
+// <!-- read_when: refresh tokens, token expiry -->
+var sectionHintRe = regexp.MustCompile(`(?i)<!--\s*read_when:\s*(.*?)\s*-->`)
+
+// Discover builds the registry for a project: skills from SKILL.md files,
+// slash commands and subagents from .claude, and docs from markdown files with
+// `summary` and `read_when` frontmatter. Docs with `kind: rule` or `kind: memory`
+// are listed as items of that kind. Docs inside a package, a subdirectory with
+// its own .reflex/, are tagged with its scope. Large
+// docs, or docs with `sections: true`, are also listed section by section as
+// "path#anchor" entries so the router can pick just the part that matters.
+// Docs whose referenced files were committed after the doc was last updated
+// are flagged stale.
+func Discover(root string, cfg DiscoverConfig) (Registry, error) {
+	return discover(root, cfg, func(path, msg string) {
+		fmt.Fprintf(os.Stderr, "[reflex] warning: %s: %s\n", path, msg)
+	})
+}
+
+// warnFunc receives a problem found in a file's frontmatter.
+type warnFunc func(path, msg string)
+
+func discover(root string, cfg DiscoverConfig, warn warnFunc) (Registry, error) {
+	reg := Registry{Docs: []RegistryDoc{}, Skills: []RegistrySkill{}}
+
+	seenSkills := map[string]bool{}
+	walkSkills(root, func(path string, data []byte) {
+		fm := parseFrontmatter(string(data))
+		name, desc := fmString(fm, "name"), fmString(fm, "description")
+		if name == "" || desc == "" || seenSkills[name] {
+			return
+		}
+		seenSkills[name] = true
+		reg.Skills = append(reg.Skills, RegistrySkill{Name: name, Description: desc, Requires: fmList(fm, "requires"), Inject: fmInject(fm, path, warn), When: fmWhen(fm, path, warn)})
+	})
+
+	reg.Items = discoverItems(root, warn)
+
+	refs := map[string][]string{} // doc file -> project files it references, for staleness
+	packageOf := packageFinder(root)
+	err := walkDocs(root, cfg, func(rel string, data []byte) {
+		if it, ok := docItem(rel, string(data), warn); ok {
+			it.Scope = packageOf(rel)
+			reg.Items = append(reg.Items, it)
+			return
+		}
+		docs := discoverDoc(rel, string(data), cfg, warn)
+		if len(docs) > 0 && !cfg.SkipStale {
+			refs[docs[0].Path] = docReferences(root, docs[0].Path, string(data))
+		}
+		for i := range docs {
+			docs[i].Scope = packageOf(rel)
+		}
+		reg.Docs = append(reg.Docs, docs...)
+	})
+	markStale(root, reg.Docs, refs)
+	sort.SliceStable(reg.Skills, func(i, j int) bool { return reg.Skills[i].Name < reg.Skills[j].Name })
+	return reg, err
+}
+
+// walkSkills calls fn with every SKILL.md under the skill directories.
+func walkSkills(root string, fn func(path string, data []byte)) {
+	for _, dir := range skillDirs {
+		filepath.WalkDir(filepath.Join(root, dir), func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || d.Name() != "SKILL.md" {
+				return nil
+			}
+			if data, err := os.ReadFile(path); err == nil {
+				fn(path, data)
+			}
+			return nil
+		})
+	}
+}
+
+// walkDocs calls fn with every markdown file discovery considers for docs,
+// by slash-separated path relative to root, skipping noise and skill dirs.
+func walkDocs(root string, cfg DiscoverConfig, fn func(rel string, data []byte)) error {
+	skip := map[string]bool{}
+	for _, d := range append(append([]string{}, defaultSkipDirs...), cfg.SkipDirs...) {
+		skip[d] = true
+	}
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, path)
+		if d.IsDir() {
+			if path != root && (skip[d.Name()] || isDefinitionDir(rel)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(d.Name(), ".md") || (cfg.MaxDepth > 0 && strings.Count(rel, string(filepath.Separator)) > cfg.MaxDepth) {
+			return nil
+		}
+		if data, err := os.ReadFile(path); err == nil {
+			fn(filepath.ToSlash(rel), data)
+		}
+		return nil
+	})
+}
+
+// fmInject returns the frontmatter `inject` mode, warning about unknown values.
+func fmInject(fm map[string]any, path string, warn warnFunc) string {
+	v := fmString(fm, "inject")
+	if v != "" && !isPinned(v) {
+		warn(path, fmt.Sprintf("unknown inject mode %q (use always or session_start)", v))
+		return ""
+	}
+	return v
+}
+
+// fmWhen returns the frontmatter `when` conditions, a map from metadata key to
+// one or more patterns. Unknown keys are dropped with a warning.
+func fmWhen(fm map[string]any, path string, warn warnFunc) map[string][]string {
+	m, ok := fm["when"].(map[string]any)
+	if !ok {
+		if fm["when"] != nil {
+			warn(path, "when must be a map of metadata keys to patterns")
+		}
+		return nil
+	}
+	known := map[string]bool{}
+	for _, k := range metadataKeys {
+		known[k.Key] = true
+	}
+	when := map[string][]string{}
+	for key := range m {
+		if !known[key] {
+			warn(path, fmt.Sprintf("unknown when key %q", key))
+			continue
+		}
+		if patterns := fmList(m, key); len(patterns) > 0 {
+			when[key] = patterns
+		}
+	}
+	if len(when) == 0 {
+		return nil
+	}
+	return when
+}
+
+// isDefinitionDir reports whether rel holds skill, command, or subagent
+// definitions, which are not docs.
+func isDefinitionDir(rel string) bool {
+	for _, dirs := range [][]string{skillDirs, commandDirs, agentDirs} {
+		for _, d := range dirs {
+			if rel == d {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// docItem returns the item for a doc whose frontmatter sets `kind` to rule or
+// memory. It needs summary and read_when like any other doc.
+func docItem(rel, text string, warn warnFunc) (RegistryItem, bool) {
+	fm := parseFrontmatter(text)
+	kind := fmString(fm, "kind")
+	if kind == "" || kind == KindDoc {
+		return RegistryItem{}, false
+	}
+	if !docKinds[kind] {
+		warn(rel, fmt.Sprintf("unknown kind %q (use rule or memory), listed as a doc", kind))
+		return RegistryItem{}, false
+	}
+	summary, readWhen := fmString(fm, "summary"), fmList(fm, "read_when")
+	if summary == "" || len(readWhen) == 0 {
+		return RegistryItem{}, false
+	}
+	return RegistryItem{Kind: kind, ID: rel, Description: summary, Hints: readWhen, When: fmWhen(fm, rel, warn)}, true
+}
+
+// discoverDoc returns the registry entries for one markdown file: the doc
+// itself and, when sectioned, one entry per heading at the section level.
+func discoverDoc(rel, text string, cfg DiscoverConfig, warn warnFunc) []RegistryDoc {
+	fm := parseFrontmatter(text)
+	summary, readWhen := fmString(fm, "summary"), fmList(fm, "read_when")
+	if summary == "" || len(readWhen) == 0 {
+		return nil
+	}
+	docs := []RegistryDoc{{
+		Path:          rel,
+		Summary:       summary,
+		ReadWhen:      readWhen,
+		SkipWhen:      fmList(fm, "skip_when"),
+		ConflictsWith: fmList(fm, "conflicts_with"),
+		Requires:      fmList(fm, "requires"),
+		AppliesTo:     fmList(fm, "applies_to"),
+		Inject:        fmInject(fm, rel, warn),
+		LastUpdated:   fmLastUpdated(fm),
+		When:          fmWhen(fm, rel, warn),
+	}}
+	if docs[0].Inject != "" {
+		return docs // pinned docs are injected whole
+	}
+
+	lines := strings.Split(stripFrontmatter(text), "\n")
+	sectioned, explicit := fm["sections"].(bool)
+	if !explicit {
+		sectioned = cfg.sectionMinLines() > 0 && len(lines) >= cfg.sectionMinLines()
+	}
+	if !sectioned {
+		return docs
+	}
+
+	level := cfg.sectionLevel()
+	for _, h := range parseHeadings(lines) {
+		if h.Level != level {
+			continue
+		}
+		body := lines[h.Start+1 : h.End]
+		d := RegistryDoc{
+			Path:          rel + "#" + h.Slug,
+			Summary:       h.Text + " (section of " + rel + ")",
+			SkipWhen:      docs[0].SkipWhen,
+			ConflictsWith: docs[0].ConflictsWith,
+			Requires:      docs[0].Requires,
+			AppliesTo:     docs[0].AppliesTo,
+			When:          docs[0].When,
+		}
+		for _, line := range body {
+			if m := sectionHintRe.FindStringSubmatch(line); m != nil {
+				for _, hint := range strings.Split(m[1], ",") {
+					if hint = strings.TrimSpace(hint); hint != "" {
+						d.ReadWhen = append(d.ReadWhen, hint)
+					}
+				}
+			}
+		}
+		if first := firstSentence(body); first != "" {
+			d.Summary = h.Text + ": " + first + " (section of " + rel + ")"
+		}
+		docs = append(docs, d)
+	}
+	return docs
+}
+
+// firstSentence returns the first prose sentence of a section, capped at 120 characters.
+func firstSentence(lines []string) string {
+	fenced := false
+	for _, line := range lines {
+		t := strings.TrimSpace(line)
+		if strings.HasPrefix(t, "```") || strings.HasPrefix(t, "~~~") {
+			fenced = !fenced
+			continue
+		}
+		if fenced || t == "" || strings.HasPrefix(t, "#") || strings.HasPrefix(t, "<!--") || strings.HasPrefix(t, "|") {
+			continue
+		}
+		if i := strings.Index(t, ". "); i > 0 {
+			t = t[:i+1]
+		}
+		if r := []rune(t); len(r) > 120 {
+			t = string(r[:117]) + "..."
+		}
+		return t
+	}
+	return ""
+}
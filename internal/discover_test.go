@@ -0,0 +1,88 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiscover_DocsAndSkills(t *testing.T) {
+	root := t.TempDir()
+	writeDoc(t, root, "docs/auth.md", "---\nsummary: Auth guide\nread_when:\n  - login\n  - OAuth\napplies_to: [\"src/auth/**\"]\nwhen:\n  branch: release/*\n---\n# Auth\n")
+	writeDoc(t, root, "docs/notes.md", "# No frontmatter\n")
+	writeDoc(t, root, "node_modules/pkg/README.md", "---\nsummary: x\nread_when: [x]\n---\n")
+	writeDoc(t, root, ".claude/skills/deploy/SKILL.md", "---\nname: deploy\ndescription: Ship it\nsummary: not a doc\nread_when: [x]\n---\n")
+	writeDoc(t, root, ".openclaw/skills/review/SKILL.md", "---\nname: review\ndescription: Review code\n---\n")
+
+	reg, err := Discover(root, DiscoverConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reg.Docs) != 1 || reg.Docs[0].Path != "docs/auth.md" || strings.Join(reg.Docs[0].ReadWhen, ",") != "login,OAuth" ||
+		strings.Join(reg.Docs[0].AppliesTo, ",") != "src/auth/**" || strings.Join(reg.Docs[0].When[MetaBranch], ",") != "release/*" {
+		t.Errorf("unexpected docs: %+v", reg.Docs)
+	}
+	if len(reg.Skills) != 2 || reg.Skills[0].Name != "deploy" || reg.Skills[1].Name != "review" {
+		t.Errorf("unexpected skills: %+v", reg.Skills)
+	}
+}
+
+func TestDiscover_Sections(t *testing.T) {
+	root := t.TempDir()
+	writeDoc(t, root, "docs/arch.md", `---
+summary: Architecture
+read_when: [architecture]
+sections: true
+---
+# Architecture
+
+## Refresh tokens
+<!-- read_when: token expiry, refresh -->
+Tokens are refreshed by the gateway. Details follow.
+
+`+"```"+`
+## not a heading
+`+"```"+`
+
+### Storage detail
+
+## Refresh tokens
+Second section with the same title.
+`)
+
+	reg, _ := Discover(root, DiscoverConfig{})
+	if len(reg.Docs) != 3 {
+		t.Fatalf("expected doc plus two sections, got %+v", reg.Docs)
+	}
+	s := reg.Docs[1]
+	if s.Path != "docs/arch.md#refresh-tokens" || strings.Join(s.ReadWhen, ",") != "token expiry,refresh" {
+		t.Errorf("unexpected section: %+v", s)
+	}
+	if !strings.Contains(s.Summary, "Tokens are refreshed by the gateway.") {
+		t.Errorf("section summary should include its first sentence: %q", s.Summary)
+	}
+	if reg.Docs[2].Path != "docs/arch.md#refresh-tokens-1" {
+		t.Errorf("duplicate headings should get numbered anchors, got %q", reg.Docs[2].Path)
+	}
+
+	text, ok := sectionText(stripFrontmatter(mustRead(t, root, "docs/arch.md")), "refresh-tokens")
+	if !ok || !strings.Contains(text, "### Storage detail") || strings.Contains(text, "Second section") {
+		t.Errorf("section text should run to the next heading at its level, got %q", text)
+	}
+}
+
+func TestFilterRegistry_Sections(t *testing.T) {
+	registry := Registry{Docs: []RegistryDoc{
+		{Path: "docs/a.md"}, {Path: "docs/a.md#one"}, {Path: "docs/b.md"}, {Path: "docs/b.md#two"}, {Path: "docs/b.md#three"},
+	}}
+	session := SessionState{DocsRead: []string{"docs/a.md", "docs/b.md#two"}}
+
+	got := filterRegistry(registry, session)
+
+	var paths []string
+	for _, d := range got.Docs {
+		paths = append(paths, d.Path)
+	}
+	if strings.Join(paths, ",") != "docs/b.md,docs/b.md#three" {
+		t.Errorf("unexpected remaining docs: %v", paths)
+	}
+}
@@ -0,0 +1,271 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EvalCase is one labeled routing scenario: a RouteInput plus the items a
+// correct router would return.
+type EvalCase struct {
+	Name string `json:"name"`
+	RouteInput
+	Expect EvalExpect `json:"expect"`
+	// Response is a recorded raw LLM response, served instead of calling the
+	// model when evaluating with a mock provider.
+	Response string `json:"response,omitempty"`
+}
+
+// EvalExpect lists the docs and skills a case should select.
+type EvalExpect struct {
+	Docs   []string `json:"docs"`
+	Skills []string `json:"skills"`
+}
+
+// EvalDataset is a set of cases. Registry, when set, is used by cases that don't define their own.
+type EvalDataset struct {
+	Registry *Registry  `json:"registry,omitempty"`
+	Cases    []EvalCase `json:"cases"`
+}
+
+// EvalCaseResult scores one case.
+type EvalCaseResult struct {
+	Name      string   `json:"name"`
+	Expected  []string `json:"expected"`
+	Got       []string `json:"got"`
+	Precision float64  `json:"precision"`
+	Recall    float64  `json:"recall"`
+	F1        float64  `json:"f1"`
+	Exact     bool     `json:"exact"`
+	LatencyMS int64    `json:"latency_ms"`
+	Skipped   string   `json:"skip_reason,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// EvalSummary aggregates a run. Precision, recall and F1 are micro-averaged over all items.
+type EvalSummary struct {
+	Cases     int     `json:"cases"`
+	Errors    int     `json:"errors"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+	ExactRate float64 `json:"exact_match_rate"`
+	// FalseNothingRate is the share of cases expecting items where the router returned nothing.
+	FalseNothingRate float64 `json:"false_nothing_rate"`
+	// FalseInjectRate is the share of cases expecting nothing where the router returned items.
+	FalseInjectRate float64 `json:"false_inject_rate"`
+	AvgLatencyMS    int64   `json:"avg_latency_ms"`
+	MaxLatencyMS    int64   `json:"max_latency_ms"`
+}
+
+// EvalReport is the full output of RunEval.
+type EvalReport struct {
+	Model   string           `json:"model"`
+	Cases   []EvalCaseResult `json:"cases"`
+	Summary EvalSummary      `json:"summary"`
+}
+
+// LoadEvalDataset reads a dataset from YAML (.yaml/.yml) or JSONL (one case per line).
+// YAML files may be a list of cases or a mapping with `registry` and `cases`.
+func LoadEvalDataset(path string) (*EvalDataset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var ds EvalDataset
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		// Route types carry JSON tags only, so decode YAML generically and re-encode as JSON
+		var raw any
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if list, ok := raw.([]any); ok {
+			raw = map[string]any{"cases": list}
+		}
+		js, err := json.Marshal(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if err := json.Unmarshal(js, &ds); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	default:
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		scanner.Buffer(make([]byte, 0, 1024*1024), 16*1024*1024)
+		for n := 1; scanner.Scan(); n++ {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+			var c EvalCase
+			if err := json.Unmarshal([]byte(line), &c); err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", path, n, err)
+			}
+			ds.Cases = append(ds.Cases, c)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range ds.Cases {
+		c := &ds.Cases[i]
+		if c.Name == "" {
+			c.Name = fmt.Sprintf("case-%d", i+1)
+		}
+		if ds.Registry != nil && c.Registry.Len() == 0 {
+			c.Registry = *ds.Registry
+		}
+	}
+	return &ds, nil
+}
+
+// RunEval routes every case and scores the result. When mock is true, each case's
+// recorded Response is served instead of calling the model; otherwise provider is
+// used (nil builds one from cfg). The decision cache is never consulted.
+func RunEval(ds *EvalDataset, cfg *Config, provider Provider, mock bool) EvalReport {
+	report := EvalReport{Model: cfg.Provider.Model}
+	if mock {
+		report.Model = "mock"
+	}
+
+	for _, c := range ds.Cases {
+		r := &Router{Config: cfg, Provider: provider}
+		if mock {
+			r.Provider = StaticProvider{Response: c.Response}
+			if c.Response == "" {
+				r.Provider = StaticProvider{Err: fmt.Errorf("no recorded response for case %q", c.Name)}
+			}
+		}
+
+		start := time.Now()
+		result, info, err := r.Route(c.RouteInput)
+		res := EvalCaseResult{
+			Name:      c.Name,
+			Expected:  evalItems(c.Expect.Docs, c.Expect.Skills),
+			LatencyMS: time.Since(start).Milliseconds(),
+			Skipped:   info.SkipReason,
+		}
+		if err != nil {
+			res.Error = err.Error()
+			res.Got = []string{}
+		} else {
+			res.Got = evalItems(result.Docs, result.Skills)
+		}
+		tp, fp, fn := overlap(res.Expected, res.Got)
+		res.Precision, res.Recall, res.F1 = prf(tp, fp, fn)
+		res.Exact = fp == 0 && fn == 0 && err == nil
+		report.Cases = append(report.Cases, res)
+	}
+
+	report.Summary = summarize(report.Cases)
+	return report
+}
+
+// evalItems flattens docs and skills into one sorted set; skills are prefixed with "/".
+func evalItems(docs, skills []string) []string {
+	out := []string{}
+	seen := map[string]bool{}
+	for _, d := range docs {
+		if !seen[d] {
+			seen[d] = true
+			out = append(out, d)
+		}
+	}
+	for _, s := range skills {
+		if !seen["/"+s] {
+			seen["/"+s] = true
+			out = append(out, "/"+s)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// overlap counts true positives, false positives, and false negatives.
+func overlap(expected, got []string) (tp, fp, fn int) {
+	want := make(map[string]bool, len(expected))
+	for _, e := range expected {
+		want[e] = true
+	}
+	for _, g := range got {
+		if want[g] {
+			tp++
+			delete(want, g)
+		} else {
+			fp++
+		}
+	}
+	return tp, fp, len(want)
+}
+
+// prf computes precision, recall, and F1. An empty prediction for an empty
+// expectation is a perfect score.
+func prf(tp, fp, fn int) (p, r, f1 float64) {
+	p, r = 1, 1
+	if tp+fp > 0 {
+		p = float64(tp) / float64(tp+fp)
+	}
+	if tp+fn > 0 {
+		r = float64(tp) / float64(tp+fn)
+	}
+	if p+r > 0 {
+		f1 = 2 * p * r / (p + r)
+	}
+	return p, r, f1
+}
+
+func summarize(cases []EvalCaseResult) EvalSummary {
+	s := EvalSummary{Cases: len(cases)}
+	if len(cases) == 0 {
+		return s
+	}
+	var tp, fp, fn, exact, expectSome, falseNothing, expectNone, falseInject int
+	var totalLatency int64
+	for _, c := range cases {
+		ctp, cfp, cfn := overlap(c.Expected, c.Got)
+		tp, fp, fn = tp+ctp, fp+cfp, fn+cfn
+		if c.Error != "" {
+			s.Errors++
+		}
+		if c.Exact {
+			exact++
+		}
+		if len(c.Expected) > 0 {
+			expectSome++
+			if len(c.Got) == 0 {
+				falseNothing++
+			}
+		} else {
+			expectNone++
+			if len(c.Got) > 0 {
+				falseInject++
+			}
+		}
+		totalLatency += c.LatencyMS
+		if c.LatencyMS > s.MaxLatencyMS {
+			s.MaxLatencyMS = c.LatencyMS
+		}
+	}
+	s.Precision, s.Recall, s.F1 = prf(tp, fp, fn)
+	s.ExactRate = float64(exact) / float64(len(cases))
+	if expectSome > 0 {
+		s.FalseNothingRate = float64(falseNothing) / float64(expectSome)
+	}
+	if expectNone > 0 {
+		s.FalseInjectRate = float64(falseInject) / float64(expectNone)
+	}
+	s.AvgLatencyMS = totalLatency / int64(len(cases))
+	return s
+}
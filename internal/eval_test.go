@@ -0,0 +1,107 @@
+package internal
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadEvalDataset_YAMLUsesSharedRegistry(t *testing.T) {
+	ds, err := LoadEvalDataset(filepath.Join("testdata", "eval.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ds.Cases) != 4 {
+		t.Fatalf("expected 4 cases, got %d", len(ds.Cases))
+	}
+	c := ds.Cases[0]
+	if c.Name != "oauth question" || len(c.Registry.Docs) != 2 {
+		t.Errorf("case should inherit the shared registry, got %+v", c.Registry)
+	}
+	if len(c.Registry.Docs[0].ReadWhen) != 3 {
+		t.Errorf("read_when should survive YAML decoding, got %v", c.Registry.Docs[0].ReadWhen)
+	}
+	if c.Messages[0].Text != "help me set up OAuth for the dashboard" {
+		t.Errorf("unexpected message: %+v", c.Messages)
+	}
+}
+
+func TestLoadEvalDataset_JSONL(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "cases.jsonl")
+	content := `{"name":"a","messages":[{"type":"user","text":"deploy"}],"registry":{"docs":[{"path":"d.md","summary":"d"}],"skills":[]},"expect":{"docs":["d.md"]}}` + "\n\n" +
+		`{"messages":[{"type":"user","text":"hi there"}],"registry":{"docs":[{"path":"d.md","summary":"d"}],"skills":[]},"expect":{}}` + "\n"
+	if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ds, err := LoadEvalDataset(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ds.Cases) != 2 || ds.Cases[1].Name != "case-2" {
+		t.Errorf("expected 2 cases with a default name, got %+v", ds.Cases)
+	}
+}
+
+func TestRunEval_MockScoresRecordedResponses(t *testing.T) {
+	ds, err := LoadEvalDataset(filepath.Join("testdata", "eval.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := RunEval(ds, DefaultConfig(), nil, true)
+
+	byName := map[string]EvalCaseResult{}
+	for _, c := range report.Cases {
+		byName[c.Name] = c
+	}
+	if !byName["oauth question"].Exact {
+		t.Error("oauth question should be an exact match")
+	}
+	plan := byName["new feature plan"]
+	if plan.Exact || plan.Precision != 0.5 || plan.Recall != 1 {
+		t.Errorf("new feature plan: expected P 0.5 R 1, got %+v", plan)
+	}
+	if ack := byName["acknowledgement"]; !ack.Exact || ack.Skipped == "" || ack.Error != "" {
+		t.Errorf("acknowledgement should be skipped without calling the provider, got %+v", ack)
+	}
+
+	s := report.Summary
+	// Items: tp=2 (auth, planning), fp=1 (deploy in plan case), fn=1 (missed deploy)
+	if math.Abs(s.Precision-2.0/3) > 1e-9 || math.Abs(s.Recall-2.0/3) > 1e-9 {
+		t.Errorf("unexpected micro precision/recall: %+v", s)
+	}
+	if s.ExactRate != 0.5 {
+		t.Errorf("expected exact-match rate 0.5, got %v", s.ExactRate)
+	}
+	if math.Abs(s.FalseNothingRate-1.0/3) > 1e-9 {
+		t.Errorf("expected false-nothing rate 1/3, got %v", s.FalseNothingRate)
+	}
+	if s.FalseInjectRate != 0 {
+		t.Errorf("expected false-inject rate 0, got %v", s.FalseInjectRate)
+	}
+}
+
+func TestRunEval_MockWithoutRecordedResponseErrors(t *testing.T) {
+	ds := &EvalDataset{Cases: []EvalCase{{
+		Name: "no response",
+		RouteInput: RouteInput{
+			Messages: []Message{{Type: "user", Text: "deploy the app"}},
+			Registry: Registry{Docs: []RegistryDoc{{Path: "d.md", Summary: "d"}}},
+		},
+		Expect: EvalExpect{Docs: []string{"d.md"}},
+	}}}
+
+	report := RunEval(ds, DefaultConfig(), nil, true)
+
+	if report.Summary.Errors != 1 || report.Cases[0].Error == "" {
+		t.Errorf("expected an error for a case without a recorded response, got %+v", report.Cases[0])
+	}
+}
+
+func TestPRF_EmptyExpectationAndPrediction(t *testing.T) {
+	p, r, f1 := prf(0, 0, 0)
+	if p != 1 || r != 1 || f1 != 1 {
+		t.Errorf("empty vs empty should score 1, got %v %v %v", p, r, f1)
+	}
+}
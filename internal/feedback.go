@@ -0,0 +1,176 @@
+package internal
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Feedback verdicts.
+const (
+	FeedbackGood   = "good"   // the decision was right
+	FeedbackBad    = "bad"    // Items (or, if empty, everything injected) should not have been
+	FeedbackMissed = "missed" // Items should have been injected
+)
+
+// Feedback labels a logged routing decision. Items are doc paths, or skill
+// names prefixed with "/".
+type Feedback struct {
+	Timestamp string   `json:"ts"`
+	LogID     string   `json:"log_id"`
+	Verdict   string   `json:"verdict"`
+	Items     []string `json:"items,omitempty"`
+	Note      string   `json:"note,omitempty"`
+}
+
+// Validate checks that f names a log entry and has a usable verdict.
+func (f Feedback) Validate() error {
+	if f.LogID == "" {
+		return fmt.Errorf("feedback needs a log_id")
+	}
+	switch f.Verdict {
+	case FeedbackGood, FeedbackBad:
+	case FeedbackMissed:
+		if len(f.Items) == 0 {
+			return fmt.Errorf("missed feedback needs the items that should have been injected")
+		}
+	default:
+		return fmt.Errorf("unknown verdict %q (use good, bad, or missed)", f.Verdict)
+	}
+	return nil
+}
+
+// NewLogID returns a random identifier for a log entry.
+func NewLogID() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// FeedbackPath returns ~/.config/reflex/feedback.jsonl, next to the log.
+func FeedbackPath() string {
+	p := LogPath()
+	if p == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(p), "feedback.jsonl")
+}
+
+// appendFeedbackFile appends one feedback line to path.
+func appendFeedbackFile(path string, f Feedback) error {
+	if path == "" {
+		return fmt.Errorf("no home directory")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	line, _ := json.Marshal(f)
+	_, werr := file.Write(append(line, '\n'))
+	if cerr := file.Close(); werr == nil {
+		werr = cerr
+	}
+	return werr
+}
+
+// readFeedbackFile returns the feedback in path, oldest first.
+func readFeedbackFile(path string) ([]Feedback, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var out []Feedback
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var f Feedback
+		if err := json.Unmarshal(scanner.Bytes(), &f); err == nil && f.LogID != "" {
+			out = append(out, f)
+		}
+	}
+	return out, scanner.Err()
+}
+
+// FeedbackDataset turns labeled decisions into eval cases. Each case starts
+// from what was injected, drops items marked bad, and adds items marked
+// missed; feedback is applied in order. Entries without a recorded input
+// can't be replayed and are counted in skipped.
+func FeedbackDataset(entries []LogEntry, feedback []Feedback) (ds *EvalDataset, skipped int) {
+	byID := map[string][]Feedback{}
+	var order []string
+	for _, f := range feedback {
+		if byID[f.LogID] == nil {
+			order = append(order, f.LogID)
+		}
+		byID[f.LogID] = append(byID[f.LogID], f)
+	}
+	logs := map[string]LogEntry{}
+	for _, e := range entries {
+		if e.ID != "" {
+			logs[e.ID] = e
+		}
+	}
+
+	ds = &EvalDataset{Cases: []EvalCase{}}
+	for _, id := range order {
+		e, ok := logs[id]
+		if !ok || e.Input == nil {
+			skipped++
+			continue
+		}
+		var expected []string
+		if e.Result != nil {
+			expected = evalItems(e.Result.Docs, e.Result.Skills)
+		}
+		for _, f := range byID[id] {
+			switch f.Verdict {
+			case FeedbackBad:
+				if len(f.Items) == 0 {
+					expected = nil
+				} else {
+					expected = without(expected, f.Items)
+				}
+			case FeedbackMissed:
+				expected = union(expected, f.Items)
+			}
+		}
+		c := EvalCase{Name: "log-" + id, RouteInput: *e.Input, Response: e.RawResponse}
+		c.SessionKey = "" // replay against the recorded session, not the live store
+		c.Expect = EvalExpect{Docs: []string{}, Skills: []string{}}
+		for _, it := range expected {
+			if name, ok := strings.CutPrefix(it, "/"); ok {
+				c.Expect.Skills = append(c.Expect.Skills, name)
+			} else {
+				c.Expect.Docs = append(c.Expect.Docs, it)
+			}
+		}
+		ds.Cases = append(ds.Cases, c)
+	}
+	return ds, skipped
+}
+
+// without returns the items of list not in drop.
+func without(list, drop []string) []string {
+	skip := make(map[string]bool, len(drop))
+	for _, d := range drop {
+		skip[d] = true
+	}
+	var out []string
+	for _, s := range list {
+		if !skip[s] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
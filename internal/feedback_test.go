@@ -0,0 +1,97 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFeedback_Validate(t *testing.T) {
+	tests := []struct {
+		f  Feedback
+		ok bool
+	}{
+		{Feedback{LogID: "a", Verdict: FeedbackGood}, true},
+		{Feedback{LogID: "a", Verdict: FeedbackBad}, true},
+		{Feedback{LogID: "a", Verdict: FeedbackMissed, Items: []string{"docs/x.md"}}, true},
+		{Feedback{LogID: "a", Verdict: FeedbackMissed}, false},
+		{Feedback{LogID: "a", Verdict: "meh"}, false},
+		{Feedback{Verdict: FeedbackGood}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.f.Validate(); (err == nil) != tt.ok {
+			t.Errorf("Validate(%+v) = %v, want ok=%v", tt.f, err, tt.ok)
+		}
+	}
+}
+
+func TestFeedbackDataset(t *testing.T) {
+	input := &RouteInput{
+		Messages:   []Message{{Type: "user", Text: "fix the webhook"}},
+		SessionKey: "live",
+	}
+	entries := []LogEntry{
+		{ID: "a1", Input: input, RawResponse: `{"docs":["docs/api.md","docs/ui.md"]}`,
+			Result: &RouteResult{Docs: []string{"docs/api.md", "docs/ui.md"}, Skills: []string{"deploy"}}},
+		{ID: "b2", Input: input, Result: &RouteResult{Docs: []string{"docs/ui.md"}}},
+		{ID: "c3", Result: &RouteResult{Docs: []string{"docs/ui.md"}}}, // input not recorded
+	}
+	feedback := []Feedback{
+		{LogID: "a1", Verdict: FeedbackBad, Items: []string{"docs/ui.md", "/deploy"}},
+		{LogID: "a1", Verdict: FeedbackMissed, Items: []string{"docs/webhooks.md", "/stripe"}},
+		{LogID: "b2", Verdict: FeedbackBad},
+		{LogID: "c3", Verdict: FeedbackGood},
+		{LogID: "gone", Verdict: FeedbackGood},
+	}
+
+	ds, skipped := FeedbackDataset(entries, feedback)
+	if skipped != 2 {
+		t.Errorf("expected 2 skipped, got %d", skipped)
+	}
+	if len(ds.Cases) != 2 {
+		t.Fatalf("expected 2 cases, got %+v", ds.Cases)
+	}
+
+	a := ds.Cases[0]
+	if a.Name != "log-a1" || a.Response == "" || a.SessionKey != "" {
+		t.Errorf("unexpected case: %+v", a)
+	}
+	if !reflect.DeepEqual(a.Expect.Docs, []string{"docs/api.md", "docs/webhooks.md"}) ||
+		!reflect.DeepEqual(a.Expect.Skills, []string{"stripe"}) {
+		t.Errorf("unexpected expectations: %+v", a.Expect)
+	}
+
+	b := ds.Cases[1]
+	if len(b.Expect.Docs) != 0 || len(b.Expect.Skills) != 0 {
+		t.Errorf("bad without items should expect nothing, got %+v", b.Expect)
+	}
+	if input.SessionKey != "live" {
+		t.Error("dataset must not modify the logged input")
+	}
+}
+
+func TestStore_Feedback(t *testing.T) {
+	for name, store := range openTestStores(t) {
+		t.Run(name, func(t *testing.T) {
+			store.AppendLog(LogEntry{ID: "aaa", Status: "ok"})
+			store.AppendLog(LogEntry{ID: "bbb", Status: "ok"})
+
+			byID, err := store.ReadLog(LogQuery{ID: "aaa"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(byID) != 1 || byID[0].ID != "aaa" {
+				t.Errorf("expected entry aaa, got %+v", byID)
+			}
+
+			store.AppendFeedback(Feedback{LogID: "aaa", Verdict: FeedbackGood})
+			store.AppendFeedback(Feedback{LogID: "bbb", Verdict: FeedbackMissed, Items: []string{"docs/x.md"}, Note: "webhooks"})
+			got, err := store.ReadFeedback()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != 2 || got[1].LogID != "bbb" || got[1].Items[0] != "docs/x.md" || got[1].Note != "webhooks" {
+				t.Errorf("unexpected feedback: %+v", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package internal
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Provider modes (provider.mode in config).
+const (
+	ModeLive     = ""
+	ModeRecord   = "record"
+	ModePlayback = "playback"
+)
+
+// Fixture is one recorded prompt/response pair, stored as <hash>.json.
+type Fixture struct {
+	Recorded string `json:"recorded"`
+	Model    string `json:"model,omitempty"`
+	Prompt   string `json:"prompt"`
+	Response string `json:"response"`
+}
+
+// DefaultFixtureDir returns ~/.config/reflex/fixtures.
+func DefaultFixtureDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "reflex", "fixtures")
+}
+
+// PromptHash identifies a prompt in a fixture directory.
+func PromptHash(prompt string) string {
+	sum := sha256.Sum256([]byte(prompt))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+func fixturePath(dir, prompt string) string {
+	return filepath.Join(dir, PromptHash(prompt)+".json")
+}
+
+// RecordingProvider forwards prompts to Provider and saves every successful
+// response to Dir. Failed calls are not recorded.
+type RecordingProvider struct {
+	Provider Provider
+	Dir      string
+	Model    string // informational, written into each fixture
+}
+
+func (p RecordingProvider) Complete(ctx context.Context, prompt string) (string, error) {
+	resp, err := p.Provider.Complete(ctx, prompt)
+	if err != nil {
+		return resp, err
+	}
+	if err := WriteFixture(p.Dir, Fixture{Model: p.Model, Prompt: prompt, Response: resp}); err != nil {
+		fmt.Fprintf(os.Stderr, "[reflex] warning: could not record fixture: %v\n", err)
+	}
+	return resp, nil
+}
+
+// PlaybackProvider serves responses recorded by RecordingProvider. A prompt
+// with no fixture is an error, so prompt changes surface instead of hitting the network.
+type PlaybackProvider struct {
+	Dir string
+}
+
+func (p PlaybackProvider) Complete(ctx context.Context, prompt string) (string, error) {
+	path := fixturePath(p.Dir, prompt)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("no recorded response for prompt %s in %s (record one with provider.mode: record)", PromptHash(prompt), p.Dir)
+	}
+	if err != nil {
+		return "", err
+	}
+	var f Fixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	return f.Response, nil
+}
+
+// WriteFixture saves f under dir, keyed by its prompt hash.
+func WriteFixture(dir string, f Fixture) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if f.Recorded == "" {
+		f.Recorded = time.Now().UTC().Format(time.RFC3339)
+	}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(fixturePath(dir, f.Prompt), append(data, '\n'), 0644)
+}
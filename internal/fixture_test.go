@@ -0,0 +1,58 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestFixture_RecordThenPlayback(t *testing.T) {
+	dir := t.TempDir()
+	rec := RecordingProvider{Provider: StaticProvider{Response: "recorded"}, Dir: dir, Model: "m"}
+	if _, err := rec.Complete(context.Background(), "prompt one"); err != nil {
+		t.Fatal(err)
+	}
+
+	play := PlaybackProvider{Dir: dir}
+	got, err := play.Complete(context.Background(), "prompt one")
+	if err != nil || got != "recorded" {
+		t.Fatalf("expected recorded response, got %q (%v)", got, err)
+	}
+
+	_, err = play.Complete(context.Background(), "prompt two")
+	if err == nil || !strings.Contains(err.Error(), PromptHash("prompt two")) {
+		t.Errorf("expected missing-fixture error naming the hash, got %v", err)
+	}
+}
+
+func TestFixture_FailedCallsNotRecorded(t *testing.T) {
+	dir := t.TempDir()
+	rec := RecordingProvider{Provider: StaticProvider{Err: errors.New("boom")}, Dir: dir}
+	if _, err := rec.Complete(context.Background(), "p"); err == nil {
+		t.Fatal("expected error to pass through")
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 0 {
+		t.Errorf("expected no fixtures, got %d", len(files))
+	}
+}
+
+func TestNewProvider_PlaybackNeedsNoAPIKey(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg := DefaultConfig()
+	cfg.Provider.Mode = ModePlayback
+	cfg.Provider.FixtureDir = "testdata/fixtures"
+	p, err := NewProvider(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := p.(PlaybackProvider); !ok {
+		t.Errorf("expected PlaybackProvider, got %T", p)
+	}
+
+	cfg.Provider.Mode = "replay"
+	if _, err := NewProvider(cfg); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+}
@@ -0,0 +1,233 @@
+package internal
+
+import (
+	"sort"
+	"strings"
+)
+
+// HintSuggestion is a phrase proposed as a new read_when hint for a doc.
+type HintSuggestion struct {
+	Phrase   string `json:"phrase"`
+	Selected int    `json:"selected"` // messages with the phrase that got the doc injected
+	Missed   int    `json:"missed"`   // messages with the phrase after which the doc was read without being injected
+}
+
+// DocHints holds the suggestions for one doc.
+type DocHints struct {
+	Path        string           `json:"path"`
+	ReadWhen    []string         `json:"read_when"` // current hints
+	Selected    int              `json:"selected"`  // messages that got the doc injected
+	Missed      int              `json:"missed"`    // messages after which it was read manually
+	Suggestions []HintSuggestion `json:"suggestions"`
+}
+
+// HintsReport is the result of mining the routing log for read_when hints.
+type HintsReport struct {
+	Entries int        `json:"entries"`  // log entries considered
+	NoInput int        `json:"no_input"` // entries without a recorded input, which can't be mined
+	Docs    []DocHints `json:"docs"`
+}
+
+// HintsOptions tunes SuggestHints.
+type HintsOptions struct {
+	MinCount int // messages a phrase must appear in for a doc (default 2)
+	Max      int // suggestions per doc (default 5)
+}
+
+// hintStopwords never start or end a suggested phrase.
+var hintStopwords = map[string]bool{
+	"a": true, "an": true, "the": true, "and": true, "or": true, "but": true, "to": true, "of": true,
+	"in": true, "on": true, "at": true, "for": true, "with": true, "from": true, "by": true, "as": true,
+	"is": true, "are": true, "was": true, "be": true, "it": true, "its": true, "this": true, "that": true,
+	"these": true, "those": true, "i": true, "we": true, "you": true, "me": true, "my": true, "our": true,
+	"your": true, "can": true, "could": true, "would": true, "should": true, "will": true, "do": true,
+	"does": true, "did": true, "how": true, "what": true, "why": true, "when": true, "where": true,
+	"which": true, "who": true, "please": true, "let": true, "lets": true, "now": true, "just": true,
+	"some": true, "any": true, "all": true, "there": true, "here": true, "into": true, "about": true,
+	"need": true, "want": true, "make": true, "get": true, "use": true, "also": true, "so": true,
+	"if": true, "then": true, "not": true, "no": true, "yes": true, "up": true, "out": true,
+}
+
+// hintMinPrecision is the share of all mined messages containing a phrase
+// that must belong to the doc, so phrases common to every request aren't
+// proposed.
+const hintMinPrecision = 0.5
+
+// SuggestHints mines log entries with recorded inputs for phrases that
+// recur in the latest user message when a doc was injected, or just before
+// the session read it without it being injected. Suggestions are made for the
+// docs in registry, skipping phrases its read_when hints already cover.
+func SuggestHints(entries []LogEntry, registry Registry, opts HintsOptions) HintsReport {
+	if opts.MinCount <= 0 {
+		opts.MinCount = 2
+	}
+	if opts.Max <= 0 {
+		opts.Max = 5
+	}
+
+	type sample struct {
+		phrases map[string]bool
+		missed  bool
+	}
+	report := HintsReport{Docs: []DocHints{}}
+	byDoc := map[string][]sample{}
+	df := map[string]int{} // phrase -> mined messages containing it
+
+	given := map[string]map[string]bool{}    // session key -> docs injected or already read
+	previous := map[string]map[string]bool{} // session key -> phrases of its previous message
+	for _, e := range entries {
+		report.Entries++
+		if e.Input == nil {
+			report.NoInput++
+			continue
+		}
+		text, _ := lastUserText(e.Input.Messages)
+		phrases := messagePhrases(text)
+		for p := range phrases {
+			df[p]++
+		}
+
+		// Docs the session read that Reflex never gave it were needed for the
+		// previous message and missed
+		if key := e.SessionKey; key != "" {
+			if given[key] == nil {
+				given[key] = map[string]bool{}
+			}
+			if e.Session != nil {
+				for _, d := range e.Session.DocsRead {
+					if !given[key][d] && previous[key] != nil {
+						byDoc[d] = append(byDoc[d], sample{previous[key], true})
+					}
+					given[key][d] = true
+				}
+			}
+			previous[key] = phrases
+		}
+
+		if e.Result == nil || (e.Status != "ok" && e.Status != "cached") {
+			continue
+		}
+		// Only the model's own picks say something about the message; pinned,
+		// file-scoped, required and reminder docs would be injected anyway
+		for _, it := range e.Result.Items {
+			if it.Kind == "doc" && it.Source == "" && !it.Reminder {
+				byDoc[it.Name] = append(byDoc[it.Name], sample{phrases, false})
+			}
+		}
+		if e.SessionKey != "" {
+			for _, d := range e.Result.Docs {
+				given[e.SessionKey][d] = true
+			}
+		}
+	}
+
+	for _, doc := range registry.Docs {
+		samples := byDoc[doc.Path]
+		if len(samples) == 0 {
+			continue
+		}
+		dh := DocHints{Path: doc.Path, ReadWhen: doc.ReadWhen, Suggestions: []HintSuggestion{}}
+		counts := map[string]*HintSuggestion{}
+		for _, s := range samples {
+			if s.missed {
+				dh.Missed++
+			} else {
+				dh.Selected++
+			}
+			for p := range s.phrases {
+				c := counts[p]
+				if c == nil {
+					c = &HintSuggestion{Phrase: p}
+					counts[p] = c
+				}
+				if s.missed {
+					c.Missed++
+				} else {
+					c.Selected++
+				}
+			}
+		}
+
+		var candidates []HintSuggestion
+		for p, c := range counts {
+			n := c.Selected + c.Missed
+			if n < opts.MinCount || float64(n)/float64(max(df[p], n)) < hintMinPrecision || hintCovered(p, doc.ReadWhen) {
+				continue
+			}
+			candidates = append(candidates, *c)
+		}
+		sort.Slice(candidates, func(i, j int) bool {
+			a, b := candidates[i], candidates[j]
+			if wa, wb := 2*a.Missed+a.Selected, 2*b.Missed+b.Selected; wa != wb {
+				return wa > wb
+			}
+			if la, lb := strings.Count(a.Phrase, " "), strings.Count(b.Phrase, " "); la != lb {
+				return la > lb // prefer the more specific phrase
+			}
+			return a.Phrase < b.Phrase
+		})
+		for _, c := range candidates {
+			if len(dh.Suggestions) == opts.Max {
+				break
+			}
+			if !subsumed(c, dh.Suggestions) {
+				dh.Suggestions = append(dh.Suggestions, c)
+			}
+		}
+		if len(dh.Suggestions) > 0 {
+			report.Docs = append(report.Docs, dh)
+		}
+	}
+	sort.SliceStable(report.Docs, func(i, j int) bool { return report.Docs[i].Path < report.Docs[j].Path })
+	return report
+}
+
+// messagePhrases returns the one- to three-word phrases of a message that
+// could serve as hints: none starts or ends with a stopword, and single words
+// are at least four letters and not generic.
+func messagePhrases(text string) map[string]bool {
+	words := strings.Fields(normalizeMessage(text))
+	phrases := map[string]bool{}
+	for i := range words {
+		if hintStopwords[words[i]] {
+			continue
+		}
+		for n := 1; n <= 3 && i+n <= len(words); n++ {
+			last := words[i+n-1]
+			if hintStopwords[last] {
+				continue
+			}
+			if n == 1 && (len([]rune(last)) < 4 || genericHints[last]) {
+				continue
+			}
+			phrases[strings.Join(words[i:i+n], " ")] = true
+		}
+	}
+	return phrases
+}
+
+// hintCovered reports whether an existing hint already contains the phrase,
+// or the phrase contains the hint, as whole words.
+func hintCovered(phrase string, hints []string) bool {
+	p := " " + phrase + " "
+	for _, h := range hints {
+		nh := " " + normalizeMessage(h) + " "
+		if nh != "  " && (strings.Contains(nh, p) || strings.Contains(p, nh)) {
+			return true
+		}
+	}
+	return false
+}
+
+// subsumed reports whether c overlaps an already chosen suggestion that is at
+// least as frequent, e.g. "refund" after "issue refund".
+func subsumed(c HintSuggestion, chosen []HintSuggestion) bool {
+	p := " " + c.Phrase + " "
+	for _, s := range chosen {
+		q := " " + s.Phrase + " "
+		if (strings.Contains(q, p) || strings.Contains(p, q)) && s.Selected+s.Missed >= c.Selected+c.Missed {
+			return true
+		}
+	}
+	return false
+}
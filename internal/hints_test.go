@@ -0,0 +1,107 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func hintEntry(key, msg string, read []string, docs ...string) LogEntry {
+	return LogEntry{
+		Status:     "ok",
+		SessionKey: key,
+		Session:    &SessionState{DocsRead: read},
+		Input:      &RouteInput{Messages: []Message{{Type: "user", Text: msg}}},
+		Result:     routedDocs(docs...),
+	}
+}
+
+// routedDocs returns a result in which the model picked docs.
+func routedDocs(docs ...string) *RouteResult {
+	r := &RouteResult{Docs: docs}
+	for _, d := range docs {
+		r.Items = append(r.Items, RankedItem{Kind: "doc", Name: d, Score: 0.9})
+	}
+	return r
+}
+
+func TestSuggestHints(t *testing.T) {
+	entries := []LogEntry{
+		hintEntry("s1", "How do I issue a partial refund?", nil, "docs/billing.md"),
+		hintEntry("s1", "thanks, now the weekly report", []string{"docs/billing.md"}),
+		hintEntry("s2", "Customer wants a partial refund for last month", nil, "docs/billing.md"),
+		hintEntry("s3", "Why did the invoice webhook fail?", nil),
+		// s3 read billing.md by itself after the webhook question: a miss
+		hintEntry("s3", "ok what next", []string{"docs/billing.md"}),
+		hintEntry("s4", "the invoice webhook retries twice", nil),
+		hintEntry("s4", "done", []string{"docs/billing.md"}),
+		{Status: "ok", Result: &RouteResult{Docs: []string{"docs/billing.md"}}}, // no input recorded
+	}
+	registry := Registry{Docs: []RegistryDoc{
+		{Path: "docs/billing.md", ReadWhen: []string{"billing", "refund"}},
+		{Path: "docs/unused.md", ReadWhen: []string{"x"}},
+	}}
+
+	report := SuggestHints(entries, registry, HintsOptions{})
+	if report.Entries != 8 || report.NoInput != 1 {
+		t.Errorf("unexpected counts: %+v", report)
+	}
+	if len(report.Docs) != 1 {
+		t.Fatalf("expected suggestions for billing.md only, got %+v", report.Docs)
+	}
+	d := report.Docs[0]
+	if d.Selected != 2 || d.Missed != 2 {
+		t.Errorf("expected 2 selected and 2 missed, got %d %d", d.Selected, d.Missed)
+	}
+	var got []string
+	for _, s := range d.Suggestions {
+		got = append(got, s.Phrase)
+	}
+	// "partial refund" is covered by the existing "refund" hint; "invoice" and
+	// "webhook" by the more specific "invoice webhook"
+	if strings.Join(got, ",") != "invoice webhook,partial" {
+		t.Errorf("got suggestions %v", got)
+	}
+	if d.Suggestions[0].Missed != 2 {
+		t.Errorf("expected the phrase to come from misses, got %+v", d.Suggestions[0])
+	}
+}
+
+func TestSuggestHints_SkipsDocsAddedWithoutModel(t *testing.T) {
+	var entries []LogEntry
+	for _, msg := range []string{"rotate the signing keys", "rotate the signing keys again", "signing keys expired"} {
+		e := hintEntry("s1", msg, nil)
+		e.Result = &RouteResult{
+			Docs: []string{"docs/style.md", "docs/keys.md", "docs/setup.md"},
+			Items: []RankedItem{
+				{Kind: "doc", Name: "docs/style.md", Score: 1, Reason: "pinned (inject: always)", Source: "pinned"},
+				{Kind: "doc", Name: "docs/setup.md", Score: 0.9, Reason: "required by docs/keys.md", Source: "required"},
+				{Kind: "doc", Name: "docs/keys.md", Score: 0.9},
+			},
+		}
+		entries = append(entries, e)
+	}
+	registry := Registry{Docs: []RegistryDoc{
+		{Path: "docs/style.md", ReadWhen: []string{"style"}, Inject: "always"},
+		{Path: "docs/keys.md", ReadWhen: []string{"keys"}},
+		{Path: "docs/setup.md", ReadWhen: []string{"setup"}},
+	}}
+
+	report := SuggestHints(entries, registry, HintsOptions{})
+	if len(report.Docs) != 1 || report.Docs[0].Path != "docs/keys.md" || report.Docs[0].Selected != 3 {
+		t.Errorf("expected suggestions for the model's pick only, got %+v", report.Docs)
+	}
+}
+
+func TestMessagePhrases(t *testing.T) {
+	p := messagePhrases("Can you fix the Stripe webhook?")
+	for _, want := range []string{"stripe", "webhook", "stripe webhook", "fix the stripe"} {
+		if !p[want] {
+			t.Errorf("missing %q in %v", want, p)
+		}
+	}
+	for _, bad := range []string{"fix", "the stripe", "can you", "you fix"} {
+		if p[bad] {
+			t.Errorf("unexpected %q", bad)
+		}
+	}
+}
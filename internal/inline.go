@@ -0,0 +1,178 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DocContent is the inlined body of a selected doc, or its most relevant sections.
+type DocContent struct {
+	Path      string   `json:"path"`
+	Text      string   `json:"text"`
+	Sections  []string `json:"sections,omitempty"` // headings of the excerpted sections; empty when the whole doc fits
+	Truncated bool     `json:"truncated,omitempty"`
+	Tokens    int      `json:"tokens"` // estimated
+}
+
+// estimateTokens approximates a token count at four characters per token.
+func estimateTokens(s string) int {
+	return (len([]rune(s)) + 3) / 4
+}
+
+// InlineDocs reads the selected docs from root and attaches their content to
+// result, within cfg's total token budget. Docs are filled in rank order; each
+// gets an equal share of what is left, so budget unused by short docs flows to
+// later ones. A doc that doesn't fit is cut down to the sections that best match
+// the conversation. Only paths present in registry are read.
+func InlineDocs(result *RouteResult, registry Registry, messages []Message, root string, cfg InlineConfig) {
+	known := make(map[string]bool, len(registry.Docs))
+	for _, d := range registry.Docs {
+		known[d.Path] = true
+	}
+	reasons := map[string]string{}
+	for _, it := range result.Items {
+		if it.Kind == "doc" {
+			reasons[it.Name] = it.Reason
+		}
+	}
+	query, _ := lastUserText(messages)
+
+	budget := cfg.maxTokens()
+	for i, path := range result.Docs {
+		if budget <= 0 {
+			break
+		}
+		if !known[path] {
+			continue
+		}
+		body, err := readDoc(root, path)
+		if err != nil || strings.TrimSpace(body) == "" {
+			continue
+		}
+		share := budget / (len(result.Docs) - i)
+		c := excerptDoc(body, query+" "+reasons[path], share)
+		c.Path = path
+		budget -= c.Tokens
+		result.Content = append(result.Content, c)
+	}
+}
+
+// readDoc returns a doc's body without frontmatter, or only the section named
+// by a "#anchor" suffix. Paths may not leave root.
+func readDoc(root, ref string) (string, error) {
+	path, anchor := splitDocRef(ref)
+	full := path
+	if !filepath.IsAbs(path) {
+		full = filepath.Join(root, path)
+	}
+	if rel, err := filepath.Rel(root, full); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", os.ErrPermission
+	}
+	data, err := os.ReadFile(full)
+	if err != nil {
+		return "", err
+	}
+	body := strings.TrimSpace(stripFrontmatter(string(data)))
+	if anchor == "" {
+		return body, nil
+	}
+	section, ok := sectionText(body, anchor)
+	if !ok {
+		return "", os.ErrNotExist
+	}
+	return section, nil
+}
+
+// docSection is a heading and the text up to the next heading.
+type docSection struct {
+	heading string
+	text    string
+}
+
+// excerptDoc returns body whole if it fits in budget tokens, otherwise the
+// sections sharing the most words with query, in document order. If even the
+// best section is too long, it is cut at a line boundary.
+func excerptDoc(body, query string, budget int) DocContent {
+	if t := estimateTokens(body); t <= budget {
+		return DocContent{Text: body, Tokens: t}
+	}
+
+	terms := map[string]bool{}
+	for _, w := range strings.Fields(normalizeMessage(query)) {
+		if len([]rune(w)) >= 3 {
+			terms[w] = true
+		}
+	}
+	// Split at every heading discovery knows, so excerpts line up with path#slug sections
+	lines := strings.Split(body, "\n")
+	var sections []docSection
+	add := func(heading string, from, to int) {
+		if text := strings.TrimSpace(strings.Join(lines[from:to], "\n")); text != "" {
+			sections = append(sections, docSection{heading, text})
+		}
+	}
+	headings := parseHeadings(lines)
+	start, heading := 0, ""
+	for _, h := range headings {
+		add(heading, start, h.Start)
+		start, heading = h.Start, h.Text
+	}
+	add(heading, start, len(lines))
+	scores := make([]int, len(sections))
+	order := make([]int, len(sections))
+	for i, s := range sections {
+		order[i] = i
+		for _, w := range strings.Fields(normalizeMessage(s.heading)) {
+			if terms[w] {
+				scores[i] += 3 // heading matches count more than body mentions
+			}
+		}
+		for _, w := range strings.Fields(normalizeMessage(s.text)) {
+			if terms[w] {
+				scores[i]++
+			}
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool { return scores[order[a]] > scores[order[b]] })
+
+	picked := make([]bool, len(sections))
+	used := 0
+	for _, i := range order {
+		if t := estimateTokens(sections[i].text); used+t <= budget {
+			picked[i] = true
+			used += t
+		}
+	}
+
+	c := DocContent{Truncated: true}
+	var parts []string
+	for i, s := range sections {
+		if picked[i] {
+			parts = append(parts, s.text)
+			c.Sections = append(c.Sections, s.heading)
+		}
+	}
+	if len(parts) == 0 && len(order) > 0 && budget > 0 {
+		best := sections[order[0]]
+		parts = []string{truncateLines(best.text, budget*4)}
+		c.Sections = []string{best.heading}
+	}
+	c.Text = strings.Join(parts, "\n\n")
+	c.Tokens = estimateTokens(c.Text)
+	return c
+}
+
+// truncateLines cuts s to at most n runes, preferring to end at a line break.
+func truncateLines(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	cut := string(r[:n])
+	if i := strings.LastIndexByte(cut, '\n'); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimSpace(cut)
+}
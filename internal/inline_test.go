@@ -0,0 +1,108 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeDoc(t *testing.T, root, path, body string) {
+	t.Helper()
+	full := filepath.Join(root, path)
+	os.MkdirAll(filepath.Dir(full), 0755)
+	if err := os.WriteFile(full, []byte(body), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInlineDocs_WholeDocWithinBudget(t *testing.T) {
+	root := t.TempDir()
+	writeDoc(t, root, "docs/a.md", "---\nsummary: a\n---\n# A\n\nShort doc.\n")
+	result := &RouteResult{Docs: []string{"docs/a.md"}}
+	registry := Registry{Docs: []RegistryDoc{{Path: "docs/a.md"}}}
+
+	InlineDocs(result, registry, nil, root, InlineConfig{})
+
+	if len(result.Content) != 1 || result.Content[0].Text != "# A\n\nShort doc." || result.Content[0].Truncated {
+		t.Errorf("expected whole doc without frontmatter, got %+v", result.Content)
+	}
+}
+
+func TestInlineDocs_ExcerptsRelevantSection(t *testing.T) {
+	root := t.TempDir()
+	filler := strings.Repeat("unrelated background text. ", 40)
+	writeDoc(t, root, "docs/ops.md", "# Ops\n\n"+filler+"\n\n## Deploying\n\nRun make deploy to ship the service.\n\n## Backups\n\n"+filler+"\n")
+	result := &RouteResult{Docs: []string{"docs/ops.md"}}
+	registry := Registry{Docs: []RegistryDoc{{Path: "docs/ops.md"}}}
+	messages := []Message{{Type: "user", Text: "how do I deploy this?"}}
+
+	InlineDocs(result, registry, messages, root, InlineConfig{MaxTokens: 100})
+
+	c := result.Content[0]
+	if !c.Truncated || len(c.Sections) == 0 || !strings.Contains(c.Text, "make deploy") {
+		t.Fatalf("expected the deploy section excerpted, got %+v", c)
+	}
+	if c.Tokens > 100 {
+		t.Errorf("excerpt exceeds budget: %d tokens", c.Tokens)
+	}
+}
+
+func TestInlineDocs_ExcerptUsesDiscoveryHeadings(t *testing.T) {
+	root := t.TempDir()
+	filler := strings.Repeat("unrelated background text. ", 40)
+	// "#deploy-tag" is not a heading, so the Deploying section keeps its example
+	writeDoc(t, root, "docs/ops.md", "## Deploying\n\nRun make deploy with:\n#deploy-tag v2\n\n## Backups\n\n"+filler+"\n")
+	result := &RouteResult{Docs: []string{"docs/ops.md"}}
+
+	InlineDocs(result, Registry{Docs: []RegistryDoc{{Path: "docs/ops.md"}}}, []Message{{Type: "user", Text: "how do I deploy?"}}, root, InlineConfig{MaxTokens: 60})
+
+	c := result.Content[0]
+	if strings.Join(c.Sections, ",") != "Deploying" || !strings.Contains(c.Text, "#deploy-tag v2") {
+		t.Errorf("expected the whole Deploying section, got %+v", c)
+	}
+}
+
+func TestInlineDocs_SharedBudgetAndUnknownPaths(t *testing.T) {
+	root := t.TempDir()
+	writeDoc(t, root, "docs/a.md", strings.Repeat("alpha line\n", 100))
+	writeDoc(t, root, "docs/b.md", strings.Repeat("beta line\n", 100))
+	writeDoc(t, root, "secret.md", "not registered")
+	result := &RouteResult{Docs: []string{"docs/a.md", "secret.md", "../outside.md", "docs/b.md"}}
+	registry := Registry{Docs: []RegistryDoc{{Path: "docs/a.md"}, {Path: "docs/b.md"}, {Path: "../outside.md"}}}
+
+	InlineDocs(result, registry, nil, root, InlineConfig{MaxTokens: 200})
+
+	total := 0
+	for _, c := range result.Content {
+		if c.Path != "docs/a.md" && c.Path != "docs/b.md" {
+			t.Errorf("unexpected inlined path %q", c.Path)
+		}
+		total += c.Tokens
+	}
+	if len(result.Content) != 2 || total > 200 {
+		t.Errorf("expected both docs within 200 tokens, got %d docs, %d tokens", len(result.Content), total)
+	}
+}
+
+func TestInlineDocs_Section(t *testing.T) {
+	root := t.TempDir()
+	writeDoc(t, root, "docs/a.md", "# A\n\nIntro.\n\n## Setup\n\nInstall it.\n\n## Usage\n\nRun it.\n")
+	result := &RouteResult{Docs: []string{"docs/a.md#usage"}}
+	registry := Registry{Docs: []RegistryDoc{{Path: "docs/a.md#usage"}}}
+
+	InlineDocs(result, registry, nil, root, InlineConfig{})
+
+	if len(result.Content) != 1 || result.Content[0].Text != "## Usage\n\nRun it." {
+		t.Errorf("expected only the Usage section, got %+v", result.Content)
+	}
+}
+
+func mustRead(t *testing.T, root, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(root, path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
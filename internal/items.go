@@ -0,0 +1,172 @@
+package internal
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Registry item kinds. Docs and skills have their own registry lists; the
+// others are RegistryItems. Callers may send kinds not listed here.
+const (
+	KindDoc      = "doc"
+	KindSkill    = "skill"
+	KindCommand  = "command"  // slash command; ID is the command name without "/"
+	KindMCPTool  = "mcp_tool" // ID is the tool name, e.g. "mcp__github__create_issue"
+	KindSubagent = "subagent" // ID is the agent name
+	KindRule     = "rule"     // ID is the rules file path
+	KindMemory   = "memory"   // ID is the memory file path, e.g. "services/api/CLAUDE.md"
+)
+
+// docKinds can be set with `kind:` in doc frontmatter to list the file as an
+// item of that kind instead of a doc.
+var docKinds = map[string]bool{KindRule: true, KindMemory: true}
+
+// commandDirs and agentDirs hold slash command and subagent definitions,
+// relative to the project root. Like skill dirs, they are excluded from docs.
+var (
+	commandDirs = []string{filepath.Join(".claude", "commands")}
+	agentDirs   = []string{filepath.Join(".claude", "agents")}
+)
+
+// Len returns the number of entries of every kind.
+func (r Registry) Len() int {
+	return len(r.Docs) + len(r.Skills) + len(r.Items)
+}
+
+// itemKey identifies an item of any kind other than doc and skill, as recorded
+// in SessionState.ItemsUsed.
+func itemKey(kind, id string) string {
+	return kind + ":" + id
+}
+
+// normalizeRegistry moves doc and skill items into Docs and Skills and drops
+// items without a kind or id, and repeated ones, with a warning each.
+func normalizeRegistry(r Registry) (Registry, []string) {
+	var warnings []string
+	out := Registry{Docs: r.Docs, Skills: r.Skills}
+	if len(r.Items) == 0 {
+		return out, nil
+	}
+	seen := map[string]bool{}
+	for i, it := range r.Items {
+		switch {
+		case it.Kind == "" || it.ID == "":
+			warnings = append(warnings, fmt.Sprintf("registry.items[%d]: needs a kind and an id, ignored", i))
+		case it.Kind == KindDoc:
+			out.Docs = append(out.Docs, RegistryDoc{Path: it.ID, Summary: it.Description, ReadWhen: it.Hints, When: it.When, Scope: it.Scope})
+		case it.Kind == KindSkill:
+			out.Skills = append(out.Skills, RegistrySkill{Name: it.ID, Description: it.Description, When: it.When})
+		case seen[itemKey(it.Kind, it.ID)]:
+			warnings = append(warnings, fmt.Sprintf("registry.items[%d]: duplicate %s, ignored", i, itemKey(it.Kind, it.ID)))
+		default:
+			seen[itemKey(it.Kind, it.ID)] = true
+			out.Items = append(out.Items, it)
+		}
+	}
+	return out, warnings
+}
+
+// listedItems drops selections that weren't offered in registry, so the model
+// can't invent commands or tools, or bring back docs left out for the session,
+// a when condition, or another package.
+func listedItems(r RouteResult, registry Registry) RouteResult {
+	listed := make(map[string]bool, registry.Len())
+	for _, d := range registry.Docs {
+		listed[itemKey(KindDoc, d.Path)] = true
+	}
+	for _, s := range registry.Skills {
+		listed[itemKey(KindSkill, s.Name)] = true
+	}
+	for _, it := range registry.Items {
+		listed[itemKey(it.Kind, it.ID)] = true
+	}
+	keep := func(kind string, names []string) []string {
+		var out []string
+		for _, n := range names {
+			if listed[itemKey(kind, n)] {
+				out = append(out, n)
+			}
+		}
+		return out
+	}
+	var items []RankedItem
+	for _, it := range r.Items {
+		if listed[itemKey(it.Kind, it.Name)] {
+			items = append(items, it)
+		}
+	}
+	r.Items, r.Docs, r.Skills = items, keep(KindDoc, r.Docs), keep(KindSkill, r.Skills)
+	return r
+}
+
+// groupByKind lists the ids in items by kind, in order.
+func groupByKind(items []RankedItem) map[string][]string {
+	if len(items) == 0 {
+		return nil
+	}
+	out := map[string][]string{}
+	for _, it := range items {
+		out[it.Kind] = append(out[it.Kind], it.Name)
+	}
+	return out
+}
+
+// discoverItems lists slash commands from .claude/commands (namespaced by
+// subdirectory, e.g. "frontend:component") and subagents from .claude/agents.
+// The description comes from frontmatter, or for commands from the first line
+// of the body as Claude Code does; `read_when` becomes their hints.
+func discoverItems(root string, warn warnFunc) []RegistryItem {
+	var items []RegistryItem
+	walkMarkdown(root, commandDirs, func(dir, path, text string) {
+		rel, _ := filepath.Rel(dir, path)
+		id := strings.ReplaceAll(filepath.ToSlash(strings.TrimSuffix(rel, ".md")), "/", ":")
+		fm := parseFrontmatter(text)
+		desc := fmString(fm, "description")
+		if desc == "" {
+			desc = firstSentence(strings.Split(stripFrontmatter(text), "\n"))
+		}
+		if it, ok := definitionItem(KindCommand, id, desc, path, fm, warn); ok {
+			items = append(items, it)
+		}
+	})
+	walkMarkdown(root, agentDirs, func(dir, path, text string) {
+		fm := parseFrontmatter(text)
+		id := fmString(fm, "name")
+		if id == "" {
+			id = strings.TrimSuffix(filepath.Base(path), ".md")
+		}
+		if it, ok := definitionItem(KindSubagent, id, fmString(fm, "description"), path, fm, warn); ok {
+			items = append(items, it)
+		}
+	})
+	return items
+}
+
+// definitionItem builds an item from a command or subagent definition,
+// warning when it has no description.
+func definitionItem(kind, id, desc, path string, fm map[string]any, warn warnFunc) (RegistryItem, bool) {
+	if desc == "" {
+		warn(path, fmt.Sprintf("%s %q has no description, skipped", kind, id))
+		return RegistryItem{}, false
+	}
+	return RegistryItem{Kind: kind, ID: id, Description: desc, Hints: fmList(fm, "read_when"), When: fmWhen(fm, path, warn)}, true
+}
+
+// walkMarkdown calls fn with every .md file under dirs, relative to root.
+func walkMarkdown(root string, dirs []string, fn func(dir, path, text string)) {
+	for _, d := range dirs {
+		dir := filepath.Join(root, d)
+		filepath.WalkDir(dir, func(path string, e fs.DirEntry, err error) error {
+			if err != nil || e.IsDir() || !strings.HasSuffix(e.Name(), ".md") {
+				return nil
+			}
+			if data, err := os.ReadFile(path); err == nil {
+				fn(dir, path, string(data))
+			}
+			return nil
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package internal
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeRegistry(t *testing.T) {
+	reg, warnings := normalizeRegistry(Registry{
+		Docs: []RegistryDoc{{Path: "docs/a.md", Summary: "a"}},
+		Items: []RegistryItem{
+			{Kind: KindDoc, ID: "docs/b.md", Description: "b", Hints: []string{"billing"}},
+			{Kind: KindSkill, ID: "deploy", Description: "ship"},
+			{Kind: KindCommand, ID: "review", Description: "review a PR"},
+			{Kind: KindCommand, ID: "review", Description: "again"},
+			{Kind: KindMCPTool, Description: "no id"},
+		},
+	})
+	if len(reg.Docs) != 2 || reg.Docs[1].Path != "docs/b.md" || reg.Docs[1].ReadWhen[0] != "billing" {
+		t.Errorf("doc items should become docs, got %+v", reg.Docs)
+	}
+	if len(reg.Skills) != 1 || reg.Skills[0].Name != "deploy" {
+		t.Errorf("skill items should become skills, got %+v", reg.Skills)
+	}
+	if len(reg.Items) != 1 || reg.Items[0].Description != "review a PR" {
+		t.Errorf("expected the first command only, got %+v", reg.Items)
+	}
+	if len(warnings) != 2 || !strings.Contains(warnings[0], "duplicate command:review") || !strings.Contains(warnings[1], "items[4]") {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+}
+
+func TestRoute_OtherKinds(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	r := &Router{Config: DefaultConfig(), Provider: StaticProvider{Response: `{"items":[
+		{"kind":"command","name":"review","score":0.9},
+		{"kind":"doc","name":"docs/pr.md","score":0.8},
+		{"kind":"mcp_tool","name":"mcp__made_up","score":0.7}
+	]}`}}
+	input := RouteInput{
+		Messages: []Message{{Type: "user", Text: "review my pull request"}},
+		Registry: Registry{
+			Docs: []RegistryDoc{{Path: "docs/pr.md", Summary: "PR guidelines"}},
+			Items: []RegistryItem{
+				{Kind: KindCommand, ID: "review", Description: "review a PR", Hints: []string{"pull request"}},
+				{Kind: KindSubagent, ID: "tester", Description: "runs tests"},
+			},
+		},
+	}
+
+	result, info, err := r.Route(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(info.Prompt, `"kind":"command","id":"review"`) || !strings.Contains(info.Prompt, "Registry items of other kinds") {
+		t.Error("items should be offered to the router")
+	}
+	want := map[string][]string{"command": {"review"}, "doc": {"docs/pr.md"}}
+	if !reflect.DeepEqual(result.ByKind, want) {
+		t.Errorf("by_kind = %v, want %v", result.ByKind, want)
+	}
+	if len(result.Skills) != 0 || len(result.Docs) != 1 {
+		t.Errorf("commands should not appear in the doc or skill views: %+v", result)
+	}
+
+	// Once used, the command is left out of the next prompt
+	input.Session = RecordInjection(input.Session, result)
+	if !reflect.DeepEqual(input.Session.ItemsUsed, []string{"command:review"}) {
+		t.Errorf("expected the command recorded, got %v", input.Session.ItemsUsed)
+	}
+	_, info, _ = r.Route(input)
+	if strings.Contains(info.Prompt, `"id":"review"`) || !strings.Contains(info.Prompt, `"id":"tester"`) {
+		t.Errorf("used items should be filtered from the prompt:\n%s", info.Prompt)
+	}
+}
+
+func TestListedItems_DropsUnofferedSelections(t *testing.T) {
+	registry := Registry{
+		Docs:   []RegistryDoc{{Path: "docs/a.md"}},
+		Skills: []RegistrySkill{{Name: "deploy"}},
+	}
+	got := listedItems(RouteResult{
+		Docs:   []string{"docs/a.md", "docs/filtered.md"},
+		Skills: []string{"deploy", "made-up"},
+		Items:  []RankedItem{{Kind: KindDoc, Name: "docs/filtered.md"}, {Kind: KindDoc, Name: "docs/a.md"}},
+	}, registry)
+	if !reflect.DeepEqual(got.Docs, []string{"docs/a.md"}) || !reflect.DeepEqual(got.Skills, []string{"deploy"}) {
+		t.Errorf("docs and skills not offered should be dropped, got %v %v", got.Docs, got.Skills)
+	}
+	if len(got.Items) != 1 || got.Items[0].Name != "docs/a.md" {
+		t.Errorf("unexpected items: %+v", got.Items)
+	}
+}
+
+func TestDiscover_CommandsAgentsAndRules(t *testing.T) {
+	root := t.TempDir()
+	writeDoc(t, root, ".claude/commands/review.md", "---\ndescription: Review the current PR\nread_when: [pull request]\n---\nReview it.\n")
+	writeDoc(t, root, ".claude/commands/frontend/component.md", "Scaffold a React component.\n\nSteps...\n")
+	writeDoc(t, root, ".claude/commands/empty.md", "---\nallowed-tools: Bash\n---\n")
+	writeDoc(t, root, ".claude/agents/tester.md", "---\nname: test-runner\ndescription: Runs the test suite\nsummary: not a doc\nread_when: [x]\n---\n")
+	writeDoc(t, root, "rules/sql.md", "---\nkind: rule\nsummary: SQL style rules\nread_when: [migration]\n---\n")
+	writeDoc(t, root, "services/api/CLAUDE.md", "---\nkind: memory\nsummary: API service notes\nread_when: [api service]\n---\n")
+	writeDoc(t, root, "docs/odd.md", "---\nkind: widget\nsummary: Odd\nread_when: [odd]\n---\n")
+
+	var warnings []string
+	reg, err := discover(root, DiscoverConfig{}, func(path, msg string) { warnings = append(warnings, msg) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, it := range reg.Items {
+		got = append(got, itemKey(it.Kind, it.ID)+"="+it.Description)
+	}
+	want := []string{
+		"command:frontend:component=Scaffold a React component.",
+		"command:review=Review the current PR",
+		"subagent:test-runner=Runs the test suite",
+		"rule:rules/sql.md=SQL style rules",
+		"memory:services/api/CLAUDE.md=API service notes",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("items:\n got %v\nwant %v", got, want)
+	}
+	if len(reg.Docs) != 1 || reg.Docs[0].Path != "docs/odd.md" {
+		t.Errorf("only the doc with an unknown kind should stay a doc, got %+v", reg.Docs)
+	}
+	if len(warnings) != 2 || !strings.Contains(warnings[0], `command "empty" has no description`) || !strings.Contains(warnings[1], `unknown kind "widget"`) {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+}
@@ -0,0 +1,261 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Lint severities. Errors fail `reflex lint`; warnings fail it with --strict.
+const (
+	LintError   = "error"
+	LintWarning = "warning"
+	LintInfo    = "info"
+)
+
+// LintIssue is one problem found in a doc's or skill's frontmatter, or in the
+// registry as a whole (Path "").
+type LintIssue struct {
+	Severity string `json:"severity"`
+	Rule     string `json:"rule"`
+	Path     string `json:"path,omitempty"`
+	Message  string `json:"message"`
+}
+
+// LintReport is the result of linting a project.
+type LintReport struct {
+	Docs           int         `json:"docs"`
+	Skills         int         `json:"skills"`
+	RegistryTokens int         `json:"registry_tokens"` // estimated size of the registry in the routing prompt
+	Errors         int         `json:"errors"`
+	Warnings       int         `json:"warnings"`
+	Issues         []LintIssue `json:"issues"`
+}
+
+// genericHints are read_when hints too vague to tell docs apart.
+var genericHints = map[string]bool{
+	"code": true, "help": true, "bug": true, "bugs": true, "fix": true, "question": true,
+	"questions": true, "project": true, "task": true, "tasks": true, "general": true,
+	"anything": true, "everything": true, "stuff": true, "work": true, "file": true,
+	"files": true, "docs": true, "documentation": true, "info": true, "misc": true,
+	"other": true, "issue": true, "issues": true, "change": true, "changes": true, "update": true,
+}
+
+// similarSummary is the word overlap (Jaccard) above which two summaries are
+// reported as near-duplicates.
+const similarSummary = 0.8
+
+// Lint checks the docs and skills of a project for problems that make
+// discovery drop them silently or make routing noisy.
+func Lint(root string, cfg *Config) (LintReport, error) {
+	var issues []LintIssue
+	add := func(severity, rule, path, format string, args ...any) {
+		issues = append(issues, LintIssue{Severity: severity, Rule: rule, Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	skillPaths := map[string][]string{}
+	walkSkills(root, func(path string, data []byte) {
+		rel, _ := filepath.Rel(root, path)
+		rel = filepath.ToSlash(rel)
+		fm, ok := lintFrontmatter(rel, string(data), add)
+		if !ok {
+			return
+		}
+		name, desc := fmString(fm, "name"), fmString(fm, "description")
+		if name == "" || desc == "" {
+			add(LintError, "frontmatter-incomplete", rel, "skill needs both name and description; it is not discovered")
+			return
+		}
+		skillPaths[name] = append(skillPaths[name], rel)
+	})
+	for name, paths := range skillPaths {
+		for _, p := range paths[1:] {
+			add(LintError, "duplicate-skill", p, "skill %q is already defined in %s; this one is ignored", name, paths[0])
+		}
+	}
+
+	err := walkDocs(root, cfg.Discover, func(rel string, data []byte) {
+		fm, ok := lintFrontmatter(rel, string(data), add)
+		if !ok {
+			if !strings.HasPrefix(string(data), "---") {
+				add(LintInfo, "no-routing-metadata", rel, "no frontmatter; the doc is never routed")
+			}
+			return
+		}
+		summary, readWhen := fmString(fm, "summary"), fmList(fm, "read_when")
+		switch {
+		case summary == "" && len(readWhen) == 0:
+			add(LintInfo, "no-routing-metadata", rel, "no summary or read_when; the doc is never routed")
+		case summary == "":
+			add(LintError, "frontmatter-incomplete", rel, "read_when without summary; the doc is not discovered")
+		case len(readWhen) == 0:
+			add(LintError, "frontmatter-incomplete", rel, "summary without read_when; the doc is not discovered")
+		}
+		for _, hint := range readWhen {
+			if h := normalizeMessage(hint); genericHints[h] || len(h) < 3 {
+				add(LintWarning, "generic-hint", rel, "read_when hint %q is too generic to route on", hint)
+			}
+		}
+	})
+	if err != nil {
+		return LintReport{}, err
+	}
+
+	reg, err := discover(root, cfg.Discover, func(path, msg string) {
+		if rel, err := filepath.Rel(root, path); err == nil && filepath.IsAbs(path) {
+			path = filepath.ToSlash(rel)
+		}
+		add(LintWarning, "frontmatter-value", path, "%s", msg)
+	})
+	if err != nil {
+		return LintReport{}, err
+	}
+	lintRegistry(reg, cfg.Lint, add)
+
+	report := LintReport{Issues: sortIssues(issues)}
+	for _, d := range reg.Docs {
+		if _, anchor := splitDocRef(d.Path); anchor == "" {
+			report.Docs++
+		}
+	}
+	report.Skills = len(reg.Skills)
+	report.RegistryTokens = registryTokens(reg)
+	for _, is := range report.Issues {
+		switch is.Severity {
+		case LintError:
+			report.Errors++
+		case LintWarning:
+			report.Warnings++
+		}
+	}
+	return report, nil
+}
+
+// lintFrontmatter parses a file's frontmatter, reporting a block that isn't
+// closed or isn't valid YAML. ok is false when there is nothing to check.
+func lintFrontmatter(rel, text string, add func(severity, rule, path, format string, args ...any)) (map[string]any, bool) {
+	front, _, ok := splitFrontmatter(text)
+	if !ok {
+		if strings.HasPrefix(text, "---\n") || strings.HasPrefix(text, "---\r\n") {
+			add(LintError, "frontmatter-invalid", rel, "frontmatter is not closed with a --- line")
+		}
+		return nil, false
+	}
+	var fm map[string]any
+	if err := yaml.Unmarshal([]byte(front), &fm); err != nil {
+		add(LintError, "frontmatter-invalid", rel, "frontmatter is not valid YAML: %v", err)
+		return nil, false
+	}
+	return fm, true
+}
+
+// lintRegistry checks references between items, near-duplicate summaries,
+// staleness, and the registry's size.
+func lintRegistry(reg Registry, cfg LintConfig, add func(severity, rule, path, format string, args ...any)) {
+	docs := map[string]bool{}
+	skills := map[string]bool{}
+	for _, d := range reg.Docs {
+		docs[d.Path] = true
+	}
+	for _, s := range reg.Skills {
+		skills[s.Name] = true
+	}
+	known := func(ref string) bool {
+		if name, ok := strings.CutPrefix(ref, "/"); ok {
+			return skills[name]
+		}
+		return docs[ref]
+	}
+
+	var files []RegistryDoc
+	for _, d := range reg.Docs {
+		if _, anchor := splitDocRef(d.Path); anchor != "" {
+			continue
+		}
+		files = append(files, d)
+		for _, ref := range d.Requires {
+			if !known(ref) {
+				add(LintError, "broken-requires", d.Path, "requires %q, which is not in the registry", ref)
+			}
+		}
+		for _, other := range d.ConflictsWith {
+			if !docs[other] {
+				add(LintWarning, "broken-conflicts", d.Path, "conflicts_with %q, which is not in the registry", other)
+			}
+		}
+		if d.Stale {
+			add(LintWarning, "stale-doc", d.Path, "%s", d.StaleReason)
+		}
+	}
+	for _, s := range reg.Skills {
+		for _, ref := range s.Requires {
+			if !known(ref) {
+				add(LintError, "broken-requires", "/"+s.Name, "requires %q, which is not in the registry", ref)
+			}
+		}
+	}
+
+	for i := range files {
+		for j := i + 1; j < len(files); j++ {
+			if sim := wordSimilarity(files[i].Summary, files[j].Summary); sim >= similarSummary {
+				add(LintWarning, "similar-summary", files[j].Path, "summary is nearly the same as %s's; the router can't tell them apart", files[i].Path)
+			}
+		}
+	}
+
+	if tokens, limit := registryTokens(reg), cfg.maxRegistryTokens(); tokens > limit {
+		add(LintWarning, "registry-size", "", "registry is about %d tokens, over lint.max_registry_tokens (%d); every route pays for it", tokens, limit)
+	}
+}
+
+// wordSimilarity returns the Jaccard similarity of two texts' word sets.
+// Texts under four words are never considered similar.
+func wordSimilarity(a, b string) float64 {
+	wa, wb := strings.Fields(normalizeMessage(a)), strings.Fields(normalizeMessage(b))
+	if len(wa) < 4 || len(wb) < 4 {
+		return 0
+	}
+	set := map[string]int{}
+	for _, w := range wa {
+		set[w] |= 1
+	}
+	for _, w := range wb {
+		set[w] |= 2
+	}
+	both := 0
+	for _, v := range set {
+		if v == 3 {
+			both++
+		}
+	}
+	return float64(both) / float64(len(set))
+}
+
+// registryTokens estimates the registry's share of the routing prompt.
+func registryTokens(reg Registry) int {
+	b, _ := json.Marshal(reg)
+	return estimateTokens(string(b))
+}
+
+// sortIssues orders issues by severity, then path, then rule.
+func sortIssues(issues []LintIssue) []LintIssue {
+	rank := map[string]int{LintError: 0, LintWarning: 1, LintInfo: 2}
+	sort.SliceStable(issues, func(i, j int) bool {
+		a, b := issues[i], issues[j]
+		if rank[a.Severity] != rank[b.Severity] {
+			return rank[a.Severity] < rank[b.Severity]
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Rule < b.Rule
+	})
+	if issues == nil {
+		return []LintIssue{}
+	}
+	return issues
+}
@@ -0,0 +1,65 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	root := t.TempDir()
+	writeDoc(t, root, "docs/auth.md", "---\nsummary: How login sessions and OAuth tokens work\nread_when: [login, code]\nrequires: [docs/missing.md, /deploy]\n---\n")
+	writeDoc(t, root, "docs/auth2.md", "---\nsummary: How login sessions and OAuth tokens work here\nread_when: [oauth]\nconflicts_with: [docs/old.md]\n---\n")
+	writeDoc(t, root, "docs/broken.md", "---\nsummary: [unclosed\n---\n")
+	writeDoc(t, root, "docs/unclosed.md", "---\nsummary: x\n")
+	writeDoc(t, root, "docs/half.md", "---\nsummary: Only a summary\n---\n")
+	writeDoc(t, root, "docs/plain.md", "# Notes\n")
+	writeDoc(t, root, "docs/pinned.md", "---\nsummary: Rules\nread_when: [rules]\ninject: sometimes\n---\n")
+	writeDoc(t, root, ".claude/skills/deploy/SKILL.md", "---\nname: deploy\ndescription: Ship it\n---\n")
+	writeDoc(t, root, ".openclaw/skills/deploy/SKILL.md", "---\nname: deploy\ndescription: Ship it too\n---\n")
+
+	cfg := DefaultConfig()
+	cfg.Discover.SkipStale = true
+	cfg.Lint.MaxRegistryTokens = 50
+	report, err := Lint(root, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, is := range report.Issues {
+		got = append(got, is.Severity+" "+is.Rule+" "+is.Path)
+	}
+	want := []string{
+		"error duplicate-skill .openclaw/skills/deploy/SKILL.md",
+		"error broken-requires docs/auth.md",
+		"error frontmatter-invalid docs/broken.md",
+		"error frontmatter-incomplete docs/half.md",
+		"error frontmatter-invalid docs/unclosed.md",
+		"warning registry-size ",
+		"warning generic-hint docs/auth.md",
+		"warning broken-conflicts docs/auth2.md",
+		"warning similar-summary docs/auth2.md",
+		"warning frontmatter-value docs/pinned.md",
+		"info no-routing-metadata docs/plain.md",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("issues:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if report.Errors != 5 || report.Warnings != 5 || report.Docs != 3 || report.Skills != 1 {
+		t.Errorf("unexpected counts: %+v", report)
+	}
+	for _, is := range report.Issues {
+		if is.Rule == "broken-requires" && !strings.Contains(is.Message, "docs/missing.md") {
+			t.Errorf("broken-requires should name the missing doc, got %q", is.Message)
+		}
+	}
+}
+
+func TestWordSimilarity(t *testing.T) {
+	if s := wordSimilarity("Deploying the API to production", "Deploying the API to staging"); s >= similarSummary {
+		t.Errorf("different targets should not be similar, got %.2f", s)
+	}
+	if s := wordSimilarity("Auth guide", "Auth guide"); s != 0 {
+		t.Errorf("short summaries are never similar, got %.2f", s)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build windows
+
+package internal
+
+import "os"
+
+// lockFile is a no-op on Windows; release builds target darwin and linux only.
+func lockFile(f *os.File) error { return nil }
+
+// unlockFile is a no-op on Windows.
+func unlockFile(f *os.File) error { return nil }
@@ -1,7 +1,11 @@
 package internal
 
 import (
+	"bufio"
+	"compress/gzip"
 	"encoding/json"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -9,22 +13,33 @@ import (
 )
 
 type LogEntry struct {
-	Timestamp    string        `json:"ts"`
-	CWD          string        `json:"cwd"`
-	Status       string        `json:"status"` // "ok", "skipped", "error"
-	SkipReason   string        `json:"skip_reason,omitempty"`
-	MessageCount int           `json:"message_count"`
-	Registry     Registry      `json:"registry"`
-	Session      *SessionState `json:"session"`
-	RawResponse  string        `json:"raw_response,omitempty"`
-	Result       *RouteResult  `json:"result"`
-	LatencyMS    int64         `json:"latency_ms"`
-	Model        string        `json:"model"`
-	Error        string        `json:"error,omitempty"`
+	ID            string         `json:"id,omitempty"` // referenced by feedback
+	Timestamp     string         `json:"ts"`
+	CWD           string         `json:"cwd"`
+	Status        string         `json:"status"` // "ok", "cached", "pinned" (skipped, but pinned items injected), "skipped", "error"
+	SkipReason    string         `json:"skip_reason,omitempty"`
+	MessageCount  int            `json:"message_count"`
+	Registry      Registry       `json:"registry"`
+	Session       *SessionState  `json:"session"`
+	SessionKey    string         `json:"session_key,omitempty"`
+	RawResponse   string         `json:"raw_response,omitempty"`
+	Result        *RouteResult   `json:"result"`
+	LatencyMS     int64          `json:"latency_ms"`
+	Model         string         `json:"model"`
+	PromptVersion string         `json:"prompt_version,omitempty"`
+	Error         string         `json:"error,omitempty"`
+	Redactions    map[string]int `json:"redactions,omitempty"` // secrets/PII replaced, by detector
+	Dropped       []string       `json:"dropped,omitempty"`    // docs removed by skip_when/conflicts_with
+	Requires      []string       `json:"requires,omitempty"`   // prerequisite chains, e.g. "a.md -> b.md"
+	Warnings      []string       `json:"warnings,omitempty"`   // input problems that didn't stop routing
+	Compliance    *Compliance    `json:"compliance,omitempty"` // reads of the docs injected on the previous turn
+	Input         *RouteInput    `json:"input,omitempty"`      // full route input, when log.record_input is on
 }
 
+// Defaults for LogConfig fields left unset.
 const maxLogSize = 500 * 1024 // 500KB
 const keepEntries = 500
+const maxArchives = 5
 
 // LogPath returns ~/.config/reflex/log.jsonl.
 func LogPath() string {
@@ -35,8 +50,15 @@ func LogPath() string {
 	return filepath.Join(home, ".config", "reflex", "log.jsonl")
 }
 
+// archivePath returns the path of the n-th rotated archive (1 = newest), e.g. log.1.jsonl.gz.
+func archivePath(path string, n int) string {
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s.%d%s.gz", strings.TrimSuffix(path, ext), n, ext)
+}
+
 // AppendLog writes a log entry to the log file.
-func AppendLog(entry LogEntry) {
+// Concurrent hook invocations are serialized with a lock file next to the log.
+func AppendLog(cfg LogConfig, entry LogEntry) {
 	p := LogPath()
 	if p == "" {
 		return
@@ -44,6 +66,17 @@ func AppendLog(entry LogEntry) {
 	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
 		return
 	}
+
+	lock, err := os.OpenFile(p+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return
+	}
+	defer lock.Close()
+	if err := lockFile(lock); err != nil {
+		return
+	}
+	defer unlockFile(lock)
+
 	f, err := os.OpenFile(p, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
 		return
@@ -54,12 +87,17 @@ func AppendLog(entry LogEntry) {
 	f.Write(append(line, '\n'))
 	f.Close()
 
-	rotateLog(p)
+	rotateLog(p, cfg)
 }
 
-func rotateLog(path string) {
+// rotateLog trims the live log to the newest KeepEntries lines once it exceeds MaxSizeKB.
+// Older lines are compressed into log.1.jsonl.gz; existing archives shift up by one
+// and anything beyond MaxArchives is deleted. Callers must hold the log lock.
+func rotateLog(path string, cfg LogConfig) {
+	cfg = cfg.withDefaults()
+
 	info, err := os.Stat(path)
-	if err != nil || info.Size() < maxLogSize {
+	if err != nil || info.Size() < int64(cfg.MaxSizeKB)*1024 {
 		return
 	}
 
@@ -69,11 +107,116 @@ func rotateLog(path string) {
 	}
 
 	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
-	if len(lines) <= keepEntries {
+	if len(lines) <= cfg.KeepEntries {
 		return
 	}
 
-	// Keep last N entries
-	kept := lines[len(lines)-keepEntries:]
-	os.WriteFile(path, []byte(strings.Join(kept, "\n")+"\n"), 0644)
+	// Keep last N entries, archive the rest. Also keep the live file under half the size
+	// limit, so a log of large entries doesn't rotate on every append.
+	cut := len(lines) - cfg.KeepEntries
+	size := 0
+	for i := len(lines) - 1; i >= cut; i-- {
+		size += len(lines[i]) + 1
+		if size > cfg.MaxSizeKB*1024/2 {
+			cut = i + 1
+			break
+		}
+	}
+	if cfg.MaxArchives > 0 {
+		if err := writeArchive(path, lines[:cut], cfg.MaxArchives); err != nil {
+			return // leave the live log intact rather than lose entries
+		}
+	}
+
+	kept := lines[cut:]
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strings.Join(kept, "\n")+"\n"), 0644); err != nil {
+		return
+	}
+	os.Rename(tmp, path)
+}
+
+// writeArchive shifts existing archives and writes lines to archive 1.
+func writeArchive(path string, lines []string, keep int) error {
+	os.Remove(archivePath(path, keep))
+	for n := keep - 1; n >= 1; n-- {
+		os.Rename(archivePath(path, n), archivePath(path, n+1))
+	}
+
+	f, err := os.Create(archivePath(path, 1))
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(f)
+	_, werr := zw.Write([]byte(strings.Join(lines, "\n") + "\n"))
+	cerr := zw.Close()
+	f.Close()
+	if werr != nil {
+		return werr
+	}
+	return cerr
+}
+
+// ReadLog returns the newest n log entries (all entries if n <= 0), oldest first.
+// Rotated archives are read only when the live log has fewer than n entries.
+func ReadLog(n int) ([]LogEntry, error) {
+	p := LogPath()
+	if p == "" {
+		return nil, nil
+	}
+
+	lines, err := readLogFile(p)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for i := 1; n <= 0 || len(lines) < n; i++ {
+		older, err := readLogFile(archivePath(p, i))
+		if err != nil {
+			break
+		}
+		lines = append(older, lines...)
+	}
+	if n > 0 && len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+
+	entries := make([]LogEntry, 0, len(lines))
+	for _, line := range lines {
+		var e LogEntry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// readLogFile returns the non-empty lines of a log file, decompressing .gz archives.
+func readLogFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	// Increase scanner buffer for large log lines
+	scanner.Buffer(make([]byte, 0, 1024*1024), 4*1024*1024)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
 }
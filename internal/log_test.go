@@ -1,9 +1,11 @@
 package internal
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -40,7 +42,7 @@ func TestRotateLog_SmallFileNotModified(t *testing.T) {
 	}
 
 	before, _ := os.ReadFile(logFile)
-	rotateLog(logFile)
+	rotateLog(logFile, LogConfig{})
 	after, _ := os.ReadFile(logFile)
 
 	if string(before) != string(after) {
@@ -70,7 +72,7 @@ func TestRotateLog_LargeFileTruncatedToKeepEntries(t *testing.T) {
 		t.Fatalf("test file should be over maxLogSize (%d), got %d", maxLogSize, info.Size())
 	}
 
-	rotateLog(logFile)
+	rotateLog(logFile, LogConfig{})
 
 	data, err := os.ReadFile(logFile)
 	if err != nil {
@@ -82,3 +84,103 @@ func TestRotateLog_LargeFileTruncatedToKeepEntries(t *testing.T) {
 		t.Errorf("expected %d lines after rotation, got %d", keepEntries, len(lines))
 	}
 }
+
+func TestRotateLog_ArchivesDroppedEntries(t *testing.T) {
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "log.jsonl")
+
+	var sb strings.Builder
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&sb, `{"ts":"2025-01-01T00:00:00Z","status":"ok","message_count":%d}`+"\n", i)
+	}
+	if err := os.WriteFile(logFile, []byte(sb.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rotateLog(logFile, LogConfig{MaxSizeKB: 1, KeepEntries: 10, MaxArchives: 2})
+
+	live, _ := readLogFile(logFile)
+	archived, err := readLogFile(archivePath(logFile, 1))
+	if err != nil {
+		t.Fatalf("expected archive to be written: %v", err)
+	}
+	if len(live)+len(archived) != 100 {
+		t.Errorf("rotation lost entries: %d live + %d archived", len(live), len(archived))
+	}
+	if len(live) > 10 {
+		t.Errorf("expected at most 10 live entries, got %d", len(live))
+	}
+}
+
+func TestRotateLog_RetainsMaxArchives(t *testing.T) {
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "log.jsonl")
+	cfg := LogConfig{MaxSizeKB: 1, KeepEntries: 5, MaxArchives: 2}
+
+	for round := 0; round < 4; round++ {
+		content := strings.Repeat(`{"ts":"2025-01-01T00:00:00Z","status":"ok","cwd":"/some/project"}`+"\n", 50)
+		if err := os.WriteFile(logFile, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		rotateLog(logFile, cfg)
+	}
+
+	for n := 1; n <= 2; n++ {
+		if _, err := os.Stat(archivePath(logFile, n)); err != nil {
+			t.Errorf("archive %d should exist: %v", n, err)
+		}
+	}
+	if _, err := os.Stat(archivePath(logFile, 3)); !os.IsNotExist(err) {
+		t.Error("archive 3 should have been deleted (max_archives: 2)")
+	}
+}
+
+func TestReadLog_SpansArchives(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg := LogConfig{MaxSizeKB: 2, KeepEntries: 5, MaxArchives: 30}
+
+	for i := 0; i < 30; i++ {
+		AppendLog(cfg, LogEntry{Status: "ok", MessageCount: i})
+	}
+
+	all, err := ReadLog(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 30 {
+		t.Fatalf("expected 30 entries across live log and archives, got %d", len(all))
+	}
+	for i, e := range all {
+		if e.MessageCount != i {
+			t.Fatalf("entries out of order at %d: got message_count %d", i, e.MessageCount)
+		}
+	}
+
+	last, _ := ReadLog(8)
+	if len(last) != 8 || last[7].MessageCount != 29 {
+		t.Errorf("expected newest 8 entries ending at 29, got %d entries", len(last))
+	}
+}
+
+func TestAppendLog_ConcurrentWritersLoseNothing(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg := LogConfig{MaxSizeKB: 2, KeepEntries: 10, MaxArchives: 50}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 40; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			AppendLog(cfg, LogEntry{Status: "ok", MessageCount: i})
+		}(i)
+	}
+	wg.Wait()
+
+	all, err := ReadLog(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 40 {
+		t.Errorf("expected 40 entries, got %d", len(all))
+	}
+}
@@ -0,0 +1,181 @@
+package internal
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// splitFrontmatter separates a leading YAML block delimited by --- lines from
+// the body. ok is false when text has no complete frontmatter block.
+func splitFrontmatter(text string) (front, body string, ok bool) {
+	if !strings.HasPrefix(text, "---\n") && !strings.HasPrefix(text, "---\r\n") {
+		return "", text, false
+	}
+	rest := text[strings.Index(text, "\n")+1:]
+	for off := 0; off < len(rest); {
+		end := strings.IndexByte(rest[off:], '\n')
+		line := rest[off:]
+		if end >= 0 {
+			line = rest[off : off+end]
+		}
+		if strings.TrimRight(line, "\r") == "---" {
+			if end < 0 {
+				return rest[:off], "", true
+			}
+			return rest[:off], rest[off+end+1:], true
+		}
+		if end < 0 {
+			break
+		}
+		off += end + 1
+	}
+	return "", text, false
+}
+
+// stripFrontmatter removes a leading YAML block delimited by --- lines.
+func stripFrontmatter(text string) string {
+	_, body, _ := splitFrontmatter(text)
+	return body
+}
+
+// parseFrontmatter decodes a file's frontmatter into a generic map. Returns nil
+// when there is none or it isn't valid YAML.
+func parseFrontmatter(text string) map[string]any {
+	front, _, ok := splitFrontmatter(text)
+	if !ok {
+		return nil
+	}
+	var m map[string]any
+	if err := yaml.Unmarshal([]byte(front), &m); err != nil {
+		return nil
+	}
+	return m
+}
+
+// fmString returns a frontmatter value as a string.
+func fmString(m map[string]any, key string) string {
+	switch v := m[key].(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case nil:
+		return ""
+	default:
+		return strings.TrimSpace(strings.Trim(yamlScalar(v), "\n"))
+	}
+}
+
+// fmList returns a frontmatter value as a list of strings; a scalar becomes a one-item list.
+func fmList(m map[string]any, key string) []string {
+	switch v := m[key].(type) {
+	case []any:
+		var out []string
+		for _, item := range v {
+			if s := strings.TrimSpace(yamlScalar(item)); s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	case nil:
+		return nil
+	default:
+		if s := fmString(m, key); s != "" {
+			return []string{s}
+		}
+		return nil
+	}
+}
+
+func yamlScalar(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, _ := yaml.Marshal(v)
+	return strings.TrimSpace(string(b))
+}
+
+// mdHeading is an ATX heading and the line range of its section, which runs
+// to the next heading of the same or a higher level.
+type mdHeading struct {
+	Level int
+	Text  string
+	Slug  string // GitHub-style anchor, unique within the document
+	Start int    // line index of the heading
+	End   int    // line index after the section's last line
+}
+
+// parseHeadings finds the ATX headings in body, ignoring fenced code blocks.
+func parseHeadings(lines []string) []mdHeading {
+	var hs []mdHeading
+	seen := map[string]int{}
+	fenced := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fenced = !fenced
+			continue
+		}
+		if fenced || !strings.HasPrefix(line, "#") {
+			continue
+		}
+		level := len(line) - len(strings.TrimLeft(line, "#"))
+		if level > 6 || (len(line) > level && line[level] != ' ' && line[level] != '\t') {
+			continue
+		}
+		text := strings.TrimSpace(strings.TrimRight(strings.TrimSpace(line[level:]), "#"))
+		slug := slugify(text)
+		if n := seen[slug]; n > 0 {
+			seen[slug] = n + 1
+			slug = slug + "-" + strconv.Itoa(n)
+		} else {
+			seen[slug] = 1
+		}
+		hs = append(hs, mdHeading{Level: level, Text: text, Slug: slug, Start: i, End: len(lines)})
+	}
+	for i := range hs {
+		for j := i + 1; j < len(hs); j++ {
+			if hs[j].Level <= hs[i].Level {
+				hs[i].End = hs[j].Start
+				break
+			}
+		}
+	}
+	return hs
+}
+
+// slugify builds a GitHub-style heading anchor: lowercase, punctuation removed,
+// spaces turned into hyphens.
+func slugify(s string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), r == '-', r == '_':
+			sb.WriteRune(r)
+		case r == ' ':
+			sb.WriteByte('-')
+		}
+	}
+	return sb.String()
+}
+
+// splitDocRef splits "docs/a.md#anchor" into the file path and the anchor.
+func splitDocRef(ref string) (path, anchor string) {
+	if i := strings.LastIndexByte(ref, '#'); i >= 0 {
+		return ref[:i], ref[i+1:]
+	}
+	return ref, ""
+}
+
+// sectionText returns the section of body under the heading with the given
+// anchor, heading included.
+func sectionText(body, anchor string) (string, bool) {
+	lines := strings.Split(body, "\n")
+	for _, h := range parseHeadings(lines) {
+		if h.Slug == anchor {
+			return strings.TrimSpace(strings.Join(lines[h.Start:h.End], "\n")), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,201 @@
+package internal
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Keys Reflex understands in RouteInput.Metadata.
+const (
+	MetaBranch   = "branch"   // current git branch
+	MetaCWD      = "cwd"      // agent's working directory, relative to the project root or absolute
+	MetaTask     = "task"     // title of the task the agent is working on
+	MetaTools    = "tools"    // names of recently used tools, newest first
+	MetaLanguage = "language" // main language of the code being worked on, e.g. "go"
+	MetaFiles    = "files"    // files recently opened or edited, relative to the project root or absolute
+)
+
+// metadataKeys lists the known keys in prompt order and whether each takes a list.
+var metadataKeys = []struct {
+	Key  string
+	List bool
+}{
+	{MetaBranch, false},
+	{MetaCWD, false},
+	{MetaLanguage, false},
+	{MetaTask, false},
+	{MetaTools, true},
+	{MetaFiles, true},
+}
+
+// MetaField is one metadata value as shown to the router.
+type MetaField struct {
+	Key   string
+	Value string
+}
+
+// ValidateMetadata returns a warning for each unknown key and each known key
+// with a value of the wrong type. Neither stops routing; both are logged.
+func ValidateMetadata(metadata map[string]any) []string {
+	known := map[string]bool{}
+	var warnings []string
+	for _, k := range metadataKeys {
+		known[k.Key] = true
+		v, ok := metadata[k.Key]
+		if !ok || v == nil {
+			continue
+		}
+		if _, isList := stringList(v); k.List && !isList {
+			warnings = append(warnings, fmt.Sprintf("metadata.%s: expected a list of strings", k.Key))
+		} else if _, isString := v.(string); !k.List && !isString {
+			warnings = append(warnings, fmt.Sprintf("metadata.%s: expected a string", k.Key))
+		}
+	}
+	var unknown []string
+	for k := range metadata {
+		if !known[k] {
+			unknown = append(unknown, k)
+		}
+	}
+	sort.Strings(unknown)
+	for _, k := range unknown {
+		warnings = append(warnings, fmt.Sprintf("metadata.%s: unknown key, ignored", k))
+	}
+	return warnings
+}
+
+// stringList returns v as a list of strings, if it is one.
+func stringList(v any) ([]string, bool) {
+	switch v := v.(type) {
+	case []string:
+		return v, true
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			out = append(out, s)
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+// metadataValues returns the values of a known key; a string is a one-item
+// list. Paths (cwd, files) are made relative to root. Missing, empty, or
+// mistyped values give nil.
+func metadataValues(metadata map[string]any, key, root string) []string {
+	if key == MetaFiles {
+		return metadataFiles(metadata, root)
+	}
+	var values []string
+	if s, ok := metadata[key].(string); ok {
+		values = []string{s}
+	} else {
+		values, _ = stringList(metadata[key])
+	}
+	var out []string
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+		if key == MetaCWD {
+			if v = relPath(v, root); v == "" {
+				continue
+			}
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+// relPath returns p as a slash-separated path relative to root, or "" when an
+// absolute p lies outside root.
+func relPath(p, root string) string {
+	if filepath.IsAbs(p) {
+		rel, err := filepath.Rel(root, p)
+		if root == "" || err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return ""
+		}
+		p = rel
+	}
+	return path.Clean(filepath.ToSlash(p))
+}
+
+// metadataContext returns the known metadata values in prompt order.
+func metadataContext(metadata map[string]any, root string) []MetaField {
+	var fields []MetaField
+	for _, k := range metadataKeys {
+		if values := metadataValues(metadata, k.Key, root); len(values) > 0 {
+			fields = append(fields, MetaField{Key: k.Key, Value: strings.Join(values, ", ")})
+		}
+	}
+	return fields
+}
+
+// matchWhen reports whether metadata satisfies an item's `when` conditions.
+// Each key needs one of its values to match one of the patterns (globs for
+// branch, cwd, and files; case-insensitive names otherwise). Keys the caller
+// didn't send are treated as satisfied, so items aren't hidden from callers
+// that send no metadata.
+func matchWhen(when map[string][]string, metadata map[string]any, root string) bool {
+	for key, patterns := range when {
+		values := metadataValues(metadata, key, root)
+		if len(values) == 0 {
+			continue
+		}
+		if !anyMatch(key, patterns, values) {
+			return false
+		}
+	}
+	return true
+}
+
+func anyMatch(key string, patterns, values []string) bool {
+	for _, p := range patterns {
+		for _, v := range values {
+			switch key {
+			case MetaFiles, MetaCWD:
+				if matchGlob(p, v) {
+					return true
+				}
+			case MetaBranch:
+				if ok, _ := path.Match(p, v); ok {
+					return true
+				}
+			default:
+				if strings.EqualFold(p, v) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// filterWhen removes docs and skills whose `when` conditions the metadata
+// doesn't satisfy.
+func filterWhen(registry Registry, metadata map[string]any, root string) Registry {
+	out := Registry{Docs: []RegistryDoc{}, Skills: []RegistrySkill{}}
+	for _, d := range registry.Docs {
+		if matchWhen(d.When, metadata, root) {
+			out.Docs = append(out.Docs, d)
+		}
+	}
+	for _, s := range registry.Skills {
+		if matchWhen(s.When, metadata, root) {
+			out.Skills = append(out.Skills, s)
+		}
+	}
+	for _, it := range registry.Items {
+		if matchWhen(it.When, metadata, root) {
+			out.Items = append(out.Items, it)
+		}
+	}
+	return out
+}
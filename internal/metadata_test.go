@@ -0,0 +1,82 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateMetadata(t *testing.T) {
+	got := ValidateMetadata(map[string]any{
+		MetaBranch: "main",
+		MetaTools:  "Edit",
+		MetaFiles:  []any{"a.go"},
+		"ticket":   "ENG-1",
+		"agent":    "x",
+	})
+	want := "metadata.tools: expected a list of strings,metadata.agent: unknown key, ignored,metadata.ticket: unknown key, ignored"
+	if strings.Join(got, ",") != want {
+		t.Errorf("got %q", got)
+	}
+	if w := ValidateMetadata(nil); w != nil {
+		t.Errorf("expected no warnings, got %v", w)
+	}
+}
+
+func TestMatchWhen(t *testing.T) {
+	meta := map[string]any{MetaBranch: "release/2.1", MetaCWD: "/repo/packages/api", MetaLanguage: "Go"}
+	cases := []struct {
+		when map[string][]string
+		want bool
+	}{
+		{map[string][]string{MetaBranch: {"release/*"}}, true},
+		{map[string][]string{MetaBranch: {"main"}}, false},
+		{map[string][]string{MetaCWD: {"packages/api/**"}}, true},
+		{map[string][]string{MetaCWD: {"packages/web/**"}}, false},
+		{map[string][]string{MetaLanguage: {"rust", "go"}}, true},
+		{map[string][]string{MetaBranch: {"release/*"}, MetaLanguage: {"rust"}}, false},
+		{map[string][]string{MetaTask: {"billing"}}, true}, // not sent: satisfied
+	}
+	for _, c := range cases {
+		if got := matchWhen(c.when, meta, "/repo"); got != c.want {
+			t.Errorf("matchWhen(%v) = %v, want %v", c.when, got, c.want)
+		}
+	}
+}
+
+func TestRoute_MetadataContextAndConditions(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	r := &Router{Config: DefaultConfig(), Root: "/repo", Provider: StaticProvider{Response: `{"items":[]}`}}
+	registry := Registry{Docs: []RegistryDoc{
+		{Path: "docs/release.md", Summary: "release checklist", When: map[string][]string{MetaBranch: {"release/*"}}},
+		{Path: "docs/api.md", Summary: "api"},
+	}}
+
+	_, info, err := r.Route(RouteInput{
+		Messages: []Message{{Type: "user", Text: "what should I check before merging?"}},
+		Registry: registry,
+		Metadata: map[string]any{MetaBranch: "feature/login", MetaCWD: "/repo/packages/api", MetaTools: []any{"Edit", "Bash"}, "mood": "ok"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(info.Prompt, "docs/release.md") {
+		t.Error("doc whose when conditions fail should not be offered")
+	}
+	for _, want := range []string{"## Agent context", "- branch: feature/login", "- cwd: packages/api", "- tools: Edit, Bash"} {
+		if !strings.Contains(info.Prompt, want) {
+			t.Errorf("prompt missing %q", want)
+		}
+	}
+	if strings.Contains(info.Prompt, "mood") {
+		t.Error("unknown metadata keys should not reach the prompt")
+	}
+	if len(info.Warnings) != 1 || !strings.Contains(info.Warnings[0], "metadata.mood") {
+		t.Errorf("expected a warning for the unknown key, got %v", info.Warnings)
+	}
+
+	// Without metadata there is no context section
+	prompt, _, _ := r.RenderPrompt(RouteInput{Messages: []Message{{Type: "user", Text: "hi there"}}, Registry: registry})
+	if strings.Contains(prompt, "## Agent context") || !strings.Contains(prompt, "docs/release.md") {
+		t.Error("without metadata, conditions are satisfied and no context is rendered")
+	}
+}
@@ -0,0 +1,113 @@
+package internal
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Modes for ScopesConfig.Mode.
+const (
+	ScopesFilter = "filter" // leave out docs of packages away from the agent's cwd
+	ScopesBoost  = "boost"  // keep them, but lower their scores
+	ScopesOff    = "off"
+)
+
+// distantPenalty scales the score of a doc from a package away from the
+// agent's cwd under scopes.mode boost.
+const distantPenalty = 0.5
+
+// packageFinder returns the package scope of a slash-separated path relative
+// to root: the nearest directory above it, below root, that has its own
+// .reflex/ directory, or "" for the project itself. Lookups are cached.
+func packageFinder(root string) func(rel string) string {
+	cache := map[string]string{}
+	var scopeOf func(dir string) string
+	scopeOf = func(dir string) string {
+		if dir == "." || dir == "/" || dir == "" {
+			return ""
+		}
+		if s, ok := cache[dir]; ok {
+			return s
+		}
+		s := scopeOf(path.Dir(dir))
+		if info, err := os.Stat(filepath.Join(root, filepath.FromSlash(dir), ".reflex")); err == nil && info.IsDir() {
+			s = dir
+		}
+		cache[dir] = s
+		return s
+	}
+	return func(rel string) string {
+		return scopeOf(path.Dir(rel))
+	}
+}
+
+// nearPackage reports whether a package scope is on the agent's path: the
+// project itself, a package the cwd is inside, or one inside the cwd. An
+// unknown cwd is near everything.
+func nearPackage(scope, cwd string) bool {
+	if scope == "" || cwd == "" || cwd == "." {
+		return true
+	}
+	return within(cwd, scope) || within(scope, cwd)
+}
+
+// within reports whether p is dir or below it.
+func within(p, dir string) bool {
+	return p == dir || strings.HasPrefix(p, dir+"/")
+}
+
+// agentCWD returns metadata.cwd relative to root, or "" when unset or outside it.
+func agentCWD(metadata map[string]any, root string) string {
+	if cwd := metadataValues(metadata, MetaCWD, root); len(cwd) > 0 {
+		return cwd[0]
+	}
+	return ""
+}
+
+// filterPackages leaves out docs and items of packages away from cwd.
+func filterPackages(registry Registry, cwd string) Registry {
+	out := Registry{Docs: []RegistryDoc{}, Skills: registry.Skills}
+	for _, d := range registry.Docs {
+		if nearPackage(d.Scope, cwd) {
+			out.Docs = append(out.Docs, d)
+		}
+	}
+	for _, it := range registry.Items {
+		if nearPackage(it.Scope, cwd) {
+			out.Items = append(out.Items, it)
+		}
+	}
+	return out
+}
+
+// penalizeDistant lowers the scores of docs and items from packages away from cwd.
+func penalizeDistant(r RouteResult, registry Registry, cwd string) RouteResult {
+	distant := map[string]bool{}
+	for _, d := range registry.Docs {
+		if !nearPackage(d.Scope, cwd) {
+			distant["doc\x00"+d.Path] = true
+		}
+	}
+	for _, it := range registry.Items {
+		if !nearPackage(it.Scope, cwd) {
+			distant[it.Kind+"\x00"+it.ID] = true
+		}
+	}
+	if len(distant) == 0 {
+		return r
+	}
+	items := make([]RankedItem, len(r.Items))
+	for i, it := range r.Items {
+		if distant[it.Kind+"\x00"+it.Name] {
+			it.Score *= distantPenalty
+			if it.Reason == "" {
+				it.Reason = "outside the current package"
+			}
+		}
+		items[i] = it
+	}
+	r.Items = items
+	return r
+}
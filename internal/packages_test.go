@@ -0,0 +1,127 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiscover_NestedPackagesWithoutDepthCap(t *testing.T) {
+	root := t.TempDir()
+	doc := "---\nsummary: %s\nread_when: [x]\n---\n"
+	writeDoc(t, root, "docs/root.md", strings.Replace(doc, "%s", "root", 1))
+	writeDoc(t, root, "packages/api/.reflex/config.yaml", "")
+	writeDoc(t, root, "packages/api/docs/auth.md", strings.Replace(doc, "%s", "api auth", 1))
+	writeDoc(t, root, "packages/api/src/handlers/v2/internal/deep/notes.md", strings.Replace(doc, "%s", "deep", 1))
+	writeDoc(t, root, "packages/web/docs/ui.md", strings.Replace(doc, "%s", "web, no .reflex", 1))
+	writeDoc(t, root, "packages/api/plugins/billing/.reflex/config.yaml", "")
+	writeDoc(t, root, "packages/api/plugins/billing/README.md", strings.Replace(doc, "%s", "billing", 1))
+
+	reg, err := Discover(root, DiscoverConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	scopes := map[string]string{}
+	for _, d := range reg.Docs {
+		scopes[d.Path] = d.Scope
+	}
+	want := map[string]string{
+		"docs/root.md":              "",
+		"packages/api/docs/auth.md": "packages/api",
+		"packages/api/src/handlers/v2/internal/deep/notes.md": "packages/api",
+		"packages/web/docs/ui.md":                             "",
+		"packages/api/plugins/billing/README.md":              "packages/api/plugins/billing",
+	}
+	if len(scopes) != len(want) {
+		t.Fatalf("expected %d docs, got %v", len(want), scopes)
+	}
+	for p, s := range want {
+		if got, ok := scopes[p]; !ok || got != s {
+			t.Errorf("%s: scope %q (found %v), want %q", p, got, ok, s)
+		}
+	}
+
+	capped, _ := Discover(root, DiscoverConfig{MaxDepth: 3})
+	for _, d := range capped.Docs {
+		if strings.Contains(d.Path, "deep/") {
+			t.Errorf("max_depth should still cap discovery when set, got %s", d.Path)
+		}
+	}
+}
+
+func TestNearPackage(t *testing.T) {
+	tests := []struct {
+		scope, cwd string
+		want       bool
+	}{
+		{"", "packages/web", true},
+		{"packages/api", "", true},
+		{"packages/api", "packages/api", true},
+		{"packages/api", "packages/api/src", true},
+		{"packages/api", "packages", true},
+		{"packages/api", "packages/web", false},
+		{"packages/api", "packages/api-gateway", false},
+	}
+	for _, tt := range tests {
+		if got := nearPackage(tt.scope, tt.cwd); got != tt.want {
+			t.Errorf("nearPackage(%q, %q) = %v, want %v", tt.scope, tt.cwd, got, tt.want)
+		}
+	}
+}
+
+func TestRoute_PackageScopes(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "packages", "web"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	registry := Registry{
+		Docs: []RegistryDoc{
+			{Path: "docs/style.md", Summary: "style"},
+			{Path: "packages/api/docs/auth.md", Summary: "api auth", Scope: "packages/api"},
+			{Path: "packages/web/docs/auth.md", Summary: "web auth", Scope: "packages/web"},
+		},
+		Items: []RegistryItem{{Kind: KindMemory, ID: "packages/api/CLAUDE.md", Description: "api notes", Scope: "packages/api"}},
+	}
+	input := RouteInput{
+		Messages: []Message{{Type: "user", Text: "how does auth work here?"}},
+		Registry: registry,
+		Metadata: map[string]any{"cwd": filepath.Join(root, "packages", "web", "src")},
+	}
+	response := `{"items":[{"kind":"doc","name":"packages/web/docs/auth.md","score":0.9},{"kind":"doc","name":"packages/api/docs/auth.md","score":0.8}]}`
+
+	// filter (default): other packages never reach the router
+	r := &Router{Config: DefaultConfig(), Provider: StaticProvider{Response: response}, Root: root}
+	result, info, err := r.Route(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(info.Prompt, "packages/api/") || !strings.Contains(info.Prompt, "docs/style.md") {
+		t.Errorf("docs and items of other packages should be filtered from the prompt:\n%s", info.Prompt)
+	}
+	if got := strings.Join(result.Docs, ","); got != "packages/web/docs/auth.md" {
+		t.Errorf("expected only the nearby doc, got %s", got)
+	}
+
+	// boost: other packages are offered, with lower scores
+	cfg := DefaultConfig()
+	cfg.Scopes.Mode = ScopesBoost
+	cfg.Ranking.MinScore = 0.5
+	r = &Router{Config: cfg, Provider: StaticProvider{Response: response}, Root: root}
+	result, info, _ = r.Route(input)
+	if !strings.Contains(info.Prompt, "packages/api/docs/auth.md") {
+		t.Error("boost mode should offer every package to the router")
+	}
+	if got := strings.Join(result.Docs, ","); got != "packages/web/docs/auth.md" {
+		t.Errorf("the distant doc should fall below min_score, got %s", got)
+	}
+
+	// no cwd: nothing is filtered
+	input.Metadata = nil
+	r = &Router{Config: DefaultConfig(), Provider: StaticProvider{Response: response}, Root: root}
+	result, _, _ = r.Route(input)
+	if len(result.Docs) != 2 {
+		t.Errorf("without a cwd every package applies, got %v", result.Docs)
+	}
+}
@@ -0,0 +1,73 @@
+package internal
+
+// Inject modes for RegistryDoc.Inject and RegistrySkill.Inject.
+const (
+	// InjectAlways injects an item whenever the session hasn't been given it yet,
+	// including items added to the registry mid-session.
+	InjectAlways = "always"
+	// InjectSessionStart injects an item only while the session has been given
+	// nothing yet, i.e. on its first injection.
+	InjectSessionStart = "session_start"
+)
+
+func isPinned(inject string) bool {
+	return inject == InjectAlways || inject == InjectSessionStart
+}
+
+// splitPinned separates pinned items from the registry. pinned holds the ones
+// due for injection in this session; rest holds everything that isn't pinned.
+func splitPinned(registry Registry, session SessionState) (pinned []RankedItem, rest Registry) {
+	given := session.docsGiven()
+	fresh := len(given) == 0 && len(session.SkillsUsed) == 0
+	due := func(inject string) bool { return inject == InjectAlways || (inject == InjectSessionStart && fresh) }
+	read := make(map[string]bool, len(given))
+	for _, d := range given {
+		read[d] = true
+	}
+	used := make(map[string]bool, len(session.SkillsUsed))
+	for _, s := range session.SkillsUsed {
+		used[s] = true
+	}
+
+	rest = Registry{Docs: []RegistryDoc{}, Skills: []RegistrySkill{}, Items: registry.Items}
+	for _, d := range registry.Docs {
+		if !isPinned(d.Inject) {
+			rest.Docs = append(rest.Docs, d)
+		} else if due(d.Inject) && !read[d.Path] {
+			pinned = append(pinned, RankedItem{Kind: "doc", Name: d.Path, Score: 1, Reason: "pinned (inject: " + d.Inject + ")", Source: "pinned"})
+		}
+	}
+	for _, s := range registry.Skills {
+		if !isPinned(s.Inject) {
+			rest.Skills = append(rest.Skills, s)
+		} else if due(s.Inject) && !used[s.Name] {
+			pinned = append(pinned, RankedItem{Kind: "skill", Name: s.Name, Score: 1, Reason: "pinned (inject: " + s.Inject + ")", Source: "pinned"})
+		}
+	}
+	return pinned, rest
+}
+
+// withPinned puts pinned items ahead of the routed ones. Ranking caps and
+// minimum scores don't apply to pinned items.
+func withPinned(result *RouteResult, pinned []RankedItem) *RouteResult {
+	if len(pinned) == 0 {
+		return result
+	}
+	out := &RouteResult{
+		Reasoning: result.Reasoning,
+		Docs:      []string{},
+		Skills:    []string{},
+		Items:     append(append([]RankedItem{}, pinned...), result.Items...),
+	}
+	for _, it := range pinned {
+		switch it.Kind {
+		case "doc":
+			out.Docs = append(out.Docs, it.Name)
+		case "skill":
+			out.Skills = append(out.Skills, it.Name)
+		}
+	}
+	out.Docs = append(out.Docs, result.Docs...)
+	out.Skills = append(out.Skills, result.Skills...)
+	return out
+}
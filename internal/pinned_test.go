@@ -0,0 +1,95 @@
+package internal
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+var errTestUnreachable = errors.New("the model should not be called")
+
+func pinnedRegistry() Registry {
+	return Registry{
+		Docs: []RegistryDoc{
+			{Path: "docs/standards.md", Summary: "coding standards", Inject: InjectSessionStart},
+			{Path: "docs/security.md", Summary: "security rules", Inject: InjectAlways},
+			{Path: "docs/auth.md", Summary: "auth"},
+		},
+		Skills: []RegistrySkill{{Name: "lint", Description: "lint", Inject: InjectAlways}},
+	}
+}
+
+func TestRoute_PinnedItemsBypassRouter(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg := DefaultConfig()
+	r := &Router{Config: cfg, Provider: StaticProvider{Response: `{"reasoning":"auth","items":[{"kind":"doc","name":"docs/auth.md","score":0.9}]}`}}
+
+	result, info, err := r.Route(RouteInput{
+		Messages: []Message{{Type: "user", Text: "how does login work?"}},
+		Registry: pinnedRegistry(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(result.Docs, ","); got != "docs/standards.md,docs/security.md,docs/auth.md" {
+		t.Errorf("expected pinned docs first, got %s", got)
+	}
+	if len(result.Skills) != 1 || result.Skills[0] != "lint" {
+		t.Errorf("expected pinned skill, got %v", result.Skills)
+	}
+	if strings.Contains(info.Prompt, "docs/standards.md") || strings.Contains(info.Prompt, `"lint"`) {
+		t.Error("pinned items should not be offered to the router")
+	}
+}
+
+func TestRoute_PinnedOnTrivialMessageAndOncePerSession(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	r := &Router{Config: DefaultConfig(), Provider: StaticProvider{Err: errTestUnreachable}}
+
+	// First message of the session is an acknowledgement: routing is skipped, pins still apply
+	result, info, err := r.Route(RouteInput{Messages: []Message{{Type: "user", Text: "ok"}}, Registry: pinnedRegistry()})
+	if err != nil || info.SkipReason == "" || len(result.Docs) != 2 {
+		t.Fatalf("expected pinned docs on a skipped route, got %+v %q %v", result, info.SkipReason, err)
+	}
+
+	// Once given, nothing pinned is repeated
+	session := RecordInjection(SessionState{}, result)
+	result, _, _ = r.Route(RouteInput{Messages: []Message{{Type: "user", Text: "thanks"}}, Registry: pinnedRegistry(), Session: session})
+	if len(result.Docs) != 0 || len(result.Skills) != 0 {
+		t.Errorf("pinned items should not repeat, got %v %v", result.Docs, result.Skills)
+	}
+
+	// Mid-session, always-pinned items not yet given are injected; session_start ones are not
+	session = SessionState{DocsRead: []string{"docs/auth.md"}, SkillsUsed: []string{"lint"}}
+	result, _, _ = r.Route(RouteInput{Messages: []Message{{Type: "user", Text: "thanks"}}, Registry: pinnedRegistry(), Session: session})
+	if len(result.Docs) != 1 || result.Docs[0] != "docs/security.md" {
+		t.Errorf("expected only the always-pinned doc, got %v", result.Docs)
+	}
+}
+
+func TestRoute_PinnedSurviveRoutingErrors(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	r := &Router{Config: DefaultConfig(), Provider: StaticProvider{Err: errors.New("provider down")}}
+
+	result, _, err := r.Route(RouteInput{Messages: []Message{{Type: "user", Text: "how does login work?"}}, Registry: pinnedRegistry()})
+	if err == nil {
+		t.Fatal("expected the provider error")
+	}
+	if got := strings.Join(result.Docs, ","); got != "docs/standards.md,docs/security.md" || len(result.Skills) != 1 {
+		t.Errorf("pinned items should be returned with the error, got %v %v", result.Docs, result.Skills)
+	}
+}
+
+func TestDiscover_InjectFrontmatter(t *testing.T) {
+	root := t.TempDir()
+	writeDoc(t, root, "docs/rules.md", "---\nsummary: rules\nread_when: [x]\ninject: session_start\nsections: true\n---\n## One\n")
+	writeDoc(t, root, ".claude/skills/lint/SKILL.md", "---\nname: lint\ndescription: lint\ninject: always\n---\n")
+
+	reg, _ := Discover(root, DiscoverConfig{})
+	if len(reg.Docs) != 1 || reg.Docs[0].Inject != InjectSessionStart {
+		t.Errorf("expected one pinned doc without sections, got %+v", reg.Docs)
+	}
+	if len(reg.Skills) != 1 || reg.Skills[0].Inject != InjectAlways {
+		t.Errorf("expected pinned skill, got %+v", reg.Skills)
+	}
+}
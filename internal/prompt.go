@@ -1,29 +1,34 @@
 package internal
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
-	"strings"
+	"fmt"
+	"os"
+	"text/template"
 )
 
-// Build constructs the routing prompt for the LLM.
-func Build(messages []Message, registry Registry) string {
-	var sb strings.Builder
+// DefaultPromptVersion identifies the built-in template in logs and cache keys.
+// Bump it whenever defaultPromptTemplate changes.
+const DefaultPromptVersion = "builtin-5"
+
+// defaultPromptTemplate is the routing prompt used when no template is configured.
+const defaultPromptTemplate = `You are a context router for an AI agent. Your job: decide what docs or skills the agent needs to read before responding to the current conversation.
 
-	sb.WriteString("You are a context router for an AI agent. Your job: decide what docs or skills the agent needs to read before responding to the current conversation.\n\n")
+## Available docs and skills
 
-	// Registry as compact JSON
-	sb.WriteString("## Available docs and skills\n\n")
-	regJSON, _ := json.Marshal(registry)
-	sb.Write(regJSON)
-	sb.WriteByte('\n')
+{{json .Registry}}
 
-	// Recent conversation as compact JSON
-	sb.WriteString("\n## Recent conversation\n\n")
-	msgJSON, _ := json.Marshal(messages)
-	sb.Write(msgJSON)
-	sb.WriteByte('\n')
+## Recent conversation
 
-	sb.WriteString(`
+{{json .Messages}}
+{{with .Context}}
+## Agent context
+
+{{range .}}- {{.Key}}: {{.Value}}
+{{end}}{{end}}
 ## Instructions
 
 Based on the conversation above, decide what the agent needs before responding.
@@ -31,18 +36,90 @@ Based on the conversation above, decide what the agent needs before responding.
 Rules:
 - Only include items that are directly relevant to the user's current message
 - Match against the read_when hints — if the user's request doesn't match, don't include it
+- Use the agent context (branch, working directory, files, tools) to break ties, not as a reason on its own
+- Never include a doc whose skip_when hints match the request, or two docs listed in each other's conflicts_with
 - When in doubt, leave it out — unnecessary context wastes the agent's attention
 - For skills, only suggest when the task clearly fits the skill's purpose
+{{- if .Registry.Items}}
+- Registry items of other kinds (commands, MCP tools, subagents, rules, memory files) are chosen the same way: match their hints, and return them with their kind and their id as the name
+{{- end}}
 - Prefer suggesting fewer, higher-relevance items over many tangentially related ones
+- Score each item from 0 to 1: 0.9 or above when the request clearly matches, around 0.5 when it only might help
 - Return ONLY valid JSON, no explanation, no markdown fences
 
 Return exactly:
-{"reasoning": "one sentence explaining your decision", "docs": ["path/to/doc.md"], "skills": ["skill-name"]}
+{"reasoning": "one sentence explaining your decision", "items": [{"kind": "doc", "name": "path/to/doc.md", "score": 0.9, "reason": "short reason"}, {"kind": "skill", "name": "skill-name", "score": 0.6, "reason": "short reason"}]}
 
 If nothing is needed (this is a valid and common outcome):
-{"reasoning": "one sentence explaining why nothing is needed", "docs": [], "skills": []}
-`)
+{"reasoning": "one sentence explaining why nothing is needed", "items": []}
+`
+
+// PromptData is what a prompt template can reference.
+type PromptData struct {
+	Registry Registry       // candidates after session filtering
+	Messages []Message      // recent conversation, redacted
+	Session  SessionState   // items already injected this session
+	Metadata map[string]any // caller-supplied route metadata, as sent
+	Context  []MetaField    // known metadata keys with values, in display order
+}
+
+// PromptTemplate is a parsed routing prompt and the version recorded with each decision.
+type PromptTemplate struct {
+	Version string
+	tmpl    *template.Template
+	custom  bool // loaded from a file, so it may render the session and raw metadata
+}
 
-	return sb.String()
+var promptFuncs = template.FuncMap{
+	// json renders a value as compact JSON, the format the built-in prompt uses
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
 }
 
+var defaultPrompt = &PromptTemplate{
+	Version: DefaultPromptVersion,
+	tmpl:    template.Must(template.New("prompt").Funcs(promptFuncs).Parse(defaultPromptTemplate)),
+}
+
+// LoadPromptTemplate parses the configured template file, or the built-in
+// template when none is set. A custom template without an explicit version is
+// versioned by a hash of its contents, so edits show up in the logs.
+func LoadPromptTemplate(cfg PromptConfig) (*PromptTemplate, error) {
+	if cfg.Template == "" {
+		if cfg.Version == "" {
+			return defaultPrompt, nil
+		}
+		return &PromptTemplate{Version: cfg.Version, tmpl: defaultPrompt.tmpl}, nil
+	}
+	data, err := os.ReadFile(cfg.Template)
+	if err != nil {
+		return nil, fmt.Errorf("prompt template: %w", err)
+	}
+	version := cfg.Version
+	if version == "" {
+		sum := sha256.Sum256(data)
+		version = "sha-" + hex.EncodeToString(sum[:])[:8]
+	}
+	tmpl, err := template.New("prompt").Funcs(promptFuncs).Option("missingkey=zero").Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("prompt template: %w", err)
+	}
+	return &PromptTemplate{Version: version, tmpl: tmpl, custom: true}, nil
+}
+
+// Build renders the built-in routing prompt.
+func Build(messages []Message, registry Registry) string {
+	prompt, _ := defaultPrompt.Render(PromptData{Messages: messages, Registry: registry})
+	return prompt
+}
+
+// Render executes the template.
+func (p *PromptTemplate) Render(data PromptData) (string, error) {
+	var buf bytes.Buffer
+	if err := p.tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("prompt template: %w", err)
+	}
+	return buf.String(), nil
+}
@@ -2,6 +2,8 @@ package internal
 
 import (
 	"encoding/json"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -104,3 +106,71 @@ func TestBuild_EmptySliceMessagesProducesValidPrompt(t *testing.T) {
 		t.Error("prompt with empty messages slice should contain '[]'")
 	}
 }
+
+func TestLoadPromptTemplate_CustomFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prompt.tmpl")
+	os.WriteFile(path, []byte(`{{len .Registry.Docs}} docs; branch {{.Metadata.branch}}; read {{json .Session.DocsRead}}`), 0644)
+
+	p, err := LoadPromptTemplate(PromptConfig{Template: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(p.Version, "sha-") {
+		t.Errorf("custom template without a version should be versioned by hash, got %q", p.Version)
+	}
+	got, err := p.Render(PromptData{
+		Registry: Registry{Docs: []RegistryDoc{{Path: "docs/a.md"}}},
+		Session:  SessionState{DocsRead: []string{"docs/b.md"}},
+		Metadata: map[string]any{"branch": "main"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != `1 docs; branch main; read ["docs/b.md"]` {
+		t.Errorf("unexpected render: %q", got)
+	}
+
+	p, _ = LoadPromptTemplate(PromptConfig{Template: path, Version: "v2"})
+	if p.Version != "v2" {
+		t.Errorf("explicit version should win, got %q", p.Version)
+	}
+}
+
+func TestLoadPromptTemplate_Defaults(t *testing.T) {
+	p, err := LoadPromptTemplate(PromptConfig{})
+	if err != nil || p.Version != DefaultPromptVersion {
+		t.Fatalf("expected built-in template, got %+v (%v)", p, err)
+	}
+	if _, err := LoadPromptTemplate(PromptConfig{Template: "missing.tmpl"}); err == nil {
+		t.Error("expected error for a missing template file")
+	}
+}
+
+func TestLoadConfig_ProjectConfig(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	project := t.TempDir()
+	os.MkdirAll(filepath.Join(project, ".reflex"), 0755)
+	os.WriteFile(filepath.Join(project, ".reflex", "config.yaml"), []byte(
+		"provider:\n  base_url: https://evil.example\n  model: small\nprompt:\n  template: prompt.tmpl\n  version: team-3\n"+
+			"redaction:\n  disabled: true\n  patterns:\n    - name: ticket\n      pattern: ACME-\\d+\n"), 0644)
+	sub := filepath.Join(project, "src")
+	os.MkdirAll(sub, 0755)
+	t.Chdir(sub)
+
+	cfg, err := LoadConfig("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Prompt.Template != filepath.Join(project, ".reflex", "prompt.tmpl") || cfg.Prompt.Version != "team-3" {
+		t.Errorf("project prompt settings not applied: %+v", cfg.Prompt)
+	}
+	if cfg.Provider.Model != "small" {
+		t.Errorf("project model not applied: %q", cfg.Provider.Model)
+	}
+	if cfg.Provider.BaseURL == "https://evil.example" {
+		t.Error("project config must not change the provider base URL")
+	}
+	if cfg.Redaction.Disabled || len(cfg.Redaction.Patterns) != 1 {
+		t.Errorf("project config may add redaction patterns but not disable redaction: %+v", cfg.Redaction)
+	}
+}
@@ -0,0 +1,102 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+	"github.com/openai/openai-go/responses"
+	"github.com/openai/openai-go/shared"
+)
+
+// Provider sends a routing prompt to a model and returns its raw text response.
+type Provider interface {
+	Complete(ctx context.Context, prompt string) (string, error)
+}
+
+// NewProvider returns the provider described by cfg: the OpenAI-compatible
+// client, optionally wrapped to record fixtures, or a playback provider that
+// never touches the network.
+func NewProvider(cfg *Config) (Provider, error) {
+	switch cfg.Provider.Mode {
+	case ModeLive:
+		return newOpenAIProvider(cfg)
+	case ModeRecord:
+		live, err := newOpenAIProvider(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return RecordingProvider{Provider: live, Dir: cfg.Provider.fixtureDir(), Model: cfg.Provider.Model}, nil
+	case ModePlayback:
+		return PlaybackProvider{Dir: cfg.Provider.fixtureDir()}, nil
+	default:
+		return nil, fmt.Errorf("unknown provider mode %q (use record or playback, or leave empty)", cfg.Provider.Mode)
+	}
+}
+
+func newOpenAIProvider(cfg *Config) (Provider, error) {
+	// Get API key: env var takes priority, then stored key
+	apiKey := ResolveAPIKey(cfg)
+	if apiKey == "" {
+		return nil, fmt.Errorf("no API key configured. Run: reflex config set api-key <your-key>")
+	}
+	return &openAIProvider{
+		client: openai.NewClient(
+			option.WithAPIKey(apiKey),
+			option.WithBaseURL(cfg.Provider.BaseURL),
+		),
+		cfg: cfg.Provider,
+	}, nil
+}
+
+// openAIProvider calls the Responses API or Chat Completions on any OpenAI-compatible endpoint.
+type openAIProvider struct {
+	client openai.Client
+	cfg    ProviderConfig
+}
+
+func (p *openAIProvider) Complete(ctx context.Context, prompt string) (string, error) {
+	useResponsesAPI := p.cfg.ResponsesAPI || strings.Contains(p.cfg.BaseURL, "api.openai.com")
+	if useResponsesAPI {
+		resp, err := p.client.Responses.New(ctx, responses.ResponseNewParams{
+			Model: shared.ResponsesModel(p.cfg.Model),
+			Input: responses.ResponseNewParamsInputUnion{
+				OfString: openai.String(prompt),
+			},
+			Reasoning: shared.ReasoningParam{
+				Effort: shared.ReasoningEffortMedium,
+			},
+		})
+		if err != nil {
+			return "", fmt.Errorf("LLM error: %w", err)
+		}
+		return strings.TrimSpace(resp.OutputText()), nil
+	}
+
+	resp, err := p.client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
+		Model: openai.ChatModel(p.cfg.Model),
+		Messages: []openai.ChatCompletionMessageParamUnion{
+			openai.UserMessage(prompt),
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("LLM error: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("LLM returned no choices")
+	}
+	return strings.TrimSpace(resp.Choices[0].Message.Content), nil
+}
+
+// StaticProvider returns the same response for every prompt. Useful for tests
+// and for evaluating against recorded responses.
+type StaticProvider struct {
+	Response string
+	Err      error
+}
+
+func (p StaticProvider) Complete(ctx context.Context, prompt string) (string, error) {
+	return p.Response, p.Err
+}
@@ -0,0 +1,84 @@
+package internal
+
+import (
+	"sort"
+)
+
+// RankedItem is one selected doc, skill, or other registry item with the
+// model's confidence in it.
+type RankedItem struct {
+	Kind   string  `json:"kind"` // "doc", "skill", or a RegistryItem kind
+	Name   string  `json:"name"` // doc path, skill name, or item id
+	Score  float64 `json:"score"`
+	Reason string  `json:"reason,omitempty"`
+	Stale  bool    `json:"stale,omitempty"` // doc may be outdated; see RegistryDoc.Stale
+	// Reminder marks a doc injected again because the agent didn't read it last time
+	Reminder bool `json:"reminder,omitempty"`
+	// Source says why an item was added without the model: "pinned", "scope"
+	// (applies_to matched a touched file), or "required". Empty for model picks.
+	Source string `json:"source,omitempty"`
+}
+
+// legacyScore is given to items returned in the plain docs/skills arrays, which
+// carry no score. They are treated as certain, as they were before ranking.
+const legacyScore = 1.0
+
+// rankResult merges ranked items with any legacy docs/skills entries, removes
+// duplicates (keeping the highest score), sorts by score, and applies the
+// minimum score and the doc and skill caps. Docs and Skills are rebuilt from
+// the ranked items so callers that only read the flat arrays keep working;
+// items of other kinds are only listed in Items.
+func rankResult(r RouteResult, cfg RankingConfig) *RouteResult {
+	best := map[string]int{}
+	items := []RankedItem{}
+	add := func(it RankedItem) {
+		if it.Name == "" || it.Kind == "" {
+			return
+		}
+		it.Score = min(max(it.Score, 0), 1)
+		key := it.Kind + "\x00" + it.Name
+		if i, ok := best[key]; ok {
+			if it.Score > items[i].Score {
+				items[i] = it
+			}
+			return
+		}
+		best[key] = len(items)
+		items = append(items, it)
+	}
+	for _, it := range r.Items {
+		add(it)
+	}
+	for _, d := range r.Docs {
+		if _, ok := best["doc\x00"+d]; !ok {
+			add(RankedItem{Kind: "doc", Name: d, Score: legacyScore})
+		}
+	}
+	for _, s := range r.Skills {
+		if _, ok := best["skill\x00"+s]; !ok {
+			add(RankedItem{Kind: "skill", Name: s, Score: legacyScore})
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Score > items[j].Score })
+
+	out := &RouteResult{Reasoning: r.Reasoning, Docs: []string{}, Skills: []string{}, Items: []RankedItem{}}
+	for _, it := range items {
+		if it.Score < cfg.MinScore {
+			continue
+		}
+		switch it.Kind {
+		case "doc":
+			if cfg.MaxDocs > 0 && len(out.Docs) >= cfg.MaxDocs {
+				continue
+			}
+			out.Docs = append(out.Docs, it.Name)
+		case "skill":
+			if cfg.MaxSkills > 0 && len(out.Skills) >= cfg.MaxSkills {
+				continue
+			}
+			out.Skills = append(out.Skills, it.Name)
+		}
+		out.Items = append(out.Items, it)
+	}
+	return out
+}
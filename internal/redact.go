@@ -0,0 +1,200 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// builtinDetectors find common secrets and PII. Order matters: earlier detectors
+// run first, so a private key block is redacted whole before the email detector
+// can match anything inside it.
+var builtinDetectors = []RedactionPattern{
+	{Name: "private_key", Pattern: `-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`},
+	{Name: "jwt", Pattern: `\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`},
+	{Name: "aws_access_key", Pattern: `\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`},
+	{Name: "aws_secret_key", Pattern: `(?i)\baws_?secret_?(?:access_?)?key\b["']?\s*[:=]\s*["']?[A-Za-z0-9/+=]{40}\b`},
+	{Name: "api_key", Pattern: `\b(?:sk-(?:ant-|proj-)?[A-Za-z0-9_-]{20,}|gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,}|xox[abposr]-[A-Za-z0-9-]{10,}|AIza[0-9A-Za-z_-]{35})\b`},
+	{Name: "email", Pattern: `\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`},
+}
+
+type compiledDetector struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// Redactor replaces secrets and PII with [REDACTED:<name>] placeholders.
+// A nil Redactor leaves text unchanged.
+type Redactor struct {
+	detectors []compiledDetector
+}
+
+// NewRedactor compiles the built-in detectors plus user-defined patterns.
+// Returns nil when redaction is disabled. Invalid user patterns are reported
+// on stderr and skipped.
+func NewRedactor(cfg RedactionConfig) *Redactor {
+	if cfg.Disabled {
+		return nil
+	}
+	r := &Redactor{}
+	for _, d := range append(append([]RedactionPattern{}, builtinDetectors...), cfg.Patterns...) {
+		re, err := regexp.Compile(d.Pattern)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[reflex] warning: invalid redaction pattern %q: %v\n", d.Name, err)
+			continue
+		}
+		name := d.Name
+		if name == "" {
+			name = "custom"
+		}
+		r.detectors = append(r.detectors, compiledDetector{name, re})
+	}
+	return r
+}
+
+// Redact returns s with every match replaced, adding match counts per detector to counts.
+func (r *Redactor) Redact(s string, counts map[string]int) string {
+	if r == nil || s == "" {
+		return s
+	}
+	for _, d := range r.detectors {
+		s = d.re.ReplaceAllStringFunc(s, func(string) string {
+			counts[d.name]++
+			return "[REDACTED:" + d.name + "]"
+		})
+	}
+	return s
+}
+
+// RedactMessages returns a copy of messages with secrets removed from each text.
+func (r *Redactor) RedactMessages(messages []Message, counts map[string]int) []Message {
+	if r == nil || messages == nil {
+		return messages
+	}
+	out := make([]Message, len(messages))
+	for i, m := range messages {
+		out[i] = Message{Type: m.Type, Text: r.Redact(m.Text, counts)}
+	}
+	return out
+}
+
+// RedactMetadata returns a copy of metadata with secrets removed from every
+// string value, including those in lists and nested objects. Keys are left alone.
+func (r *Redactor) RedactMetadata(metadata map[string]any, counts map[string]int) map[string]any {
+	if r == nil || metadata == nil {
+		return metadata
+	}
+	out := make(map[string]any, len(metadata))
+	for k, v := range metadata {
+		out[k] = r.redactValue(v, counts)
+	}
+	return out
+}
+
+func (r *Redactor) redactValue(v any, counts map[string]int) any {
+	switch v := v.(type) {
+	case string:
+		return r.Redact(v, counts)
+	case []string:
+		out := make([]string, len(v))
+		for i, s := range v {
+			out[i] = r.Redact(s, counts)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = r.redactValue(e, counts)
+		}
+		return out
+	case map[string]any:
+		return r.RedactMetadata(v, counts)
+	}
+	return v
+}
+
+// RedactRegistry returns a copy of registry with secrets removed from summaries,
+// hints, and descriptions. Paths and names are identifiers and are left alone.
+func (r *Redactor) RedactRegistry(registry Registry, counts map[string]int) Registry {
+	if r == nil {
+		return registry
+	}
+	out := Registry{Docs: make([]RegistryDoc, len(registry.Docs)), Skills: make([]RegistrySkill, len(registry.Skills))}
+	for i, d := range registry.Docs {
+		d.Summary = r.Redact(d.Summary, counts)
+		hints := make([]string, len(d.ReadWhen))
+		for j, h := range d.ReadWhen {
+			hints[j] = r.Redact(h, counts)
+		}
+		if d.ReadWhen != nil {
+			d.ReadWhen = hints
+		}
+		out.Docs[i] = d
+	}
+	for i, s := range registry.Skills {
+		s.Description = r.Redact(s.Description, counts)
+		out.Skills[i] = s
+	}
+	for _, it := range registry.Items {
+		it.Description = r.Redact(it.Description, counts)
+		hints := make([]string, len(it.Hints))
+		for j, h := range it.Hints {
+			hints[j] = r.Redact(h, counts)
+		}
+		if it.Hints != nil {
+			it.Hints = hints
+		}
+		out.Items = append(out.Items, it)
+	}
+	return out
+}
+
+// RedactLogEntry scrubs the free-text fields of a log entry before it is written
+// and adds the counts to entry.Redactions.
+func (r *Redactor) RedactLogEntry(entry *LogEntry) {
+	if r == nil {
+		return
+	}
+	counts := map[string]int{}
+	entry.Registry = r.RedactRegistry(entry.Registry, counts)
+	entry.RawResponse = r.Redact(entry.RawResponse, counts)
+	entry.Error = r.Redact(entry.Error, counts)
+	if entry.Result != nil {
+		res := *entry.Result
+		res.Reasoning = r.Redact(res.Reasoning, counts)
+		// Reasons are model text and can echo the message
+		items := make([]RankedItem, len(res.Items))
+		for i, it := range res.Items {
+			it.Reason = r.Redact(it.Reason, counts)
+			items[i] = it
+		}
+		if res.Items != nil {
+			res.Items = items
+		}
+		entry.Result = &res
+	}
+	if entry.Input != nil {
+		// Messages and metadata were already counted when routing, and the registry just above
+		dup := map[string]int{}
+		in := *entry.Input
+		in.Messages = r.RedactMessages(in.Messages, dup)
+		in.Metadata = r.RedactMetadata(in.Metadata, dup)
+		in.Registry = r.RedactRegistry(in.Registry, dup)
+		entry.Input = &in
+	}
+	entry.Redactions = mergeCounts(entry.Redactions, counts)
+}
+
+// mergeCounts adds b into a, allocating a if needed. Returns nil when both are empty.
+func mergeCounts(a, b map[string]int) map[string]int {
+	if len(b) == 0 {
+		return a
+	}
+	if a == nil {
+		a = map[string]int{}
+	}
+	for k, v := range b {
+		a[k] += v
+	}
+	return a
+}
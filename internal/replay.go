@@ -0,0 +1,104 @@
+package internal
+
+import (
+	"sort"
+	"time"
+)
+
+// ReplayCase compares a logged decision with a fresh one for the same input.
+type ReplayCase struct {
+	Timestamp string   `json:"ts"`
+	Message   string   `json:"message"` // latest user message of the recorded input
+	Before    []string `json:"before"`
+	After     []string `json:"after"`
+	Added     []string `json:"added,omitempty"`
+	Removed   []string `json:"removed,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// Changed reports whether the new decision differs from the logged one.
+func (c ReplayCase) Changed() bool {
+	return len(c.Added) > 0 || len(c.Removed) > 0
+}
+
+// ReplayReport summarizes a replay run.
+type ReplayReport struct {
+	Model     string       `json:"model"`
+	Replayed  int          `json:"replayed"`
+	Changed   int          `json:"changed"`
+	Errors    int          `json:"errors"`
+	NoInput   int          `json:"no_input"` // entries logged without log.record_input
+	LatencyMS int64        `json:"latency_ms"`
+	Cases     []ReplayCase `json:"cases"`
+}
+
+// Replay re-routes every log entry that recorded its input and diffs the decisions.
+// Entries that errored originally are skipped; there is no decision to compare.
+func Replay(entries []LogEntry, r *Router) ReplayReport {
+	report := ReplayReport{Model: r.Config.Provider.Model}
+	start := time.Now()
+
+	for _, e := range entries {
+		if e.Input == nil {
+			report.NoInput++
+			continue
+		}
+		if e.Status == "error" || e.Result == nil {
+			continue
+		}
+
+		c := ReplayCase{
+			Timestamp: e.Timestamp,
+			Before:    evalItems(e.Result.Docs, e.Result.Skills),
+		}
+		if text, ok := lastUserText(e.Input.Messages); ok {
+			c.Message = text
+		}
+
+		// Paths in metadata were relative to where the original route ran
+		er := *r
+		er.Root = e.CWD
+		result, _, err := er.Route(*e.Input)
+		if err != nil {
+			c.Error = err.Error()
+			c.After = []string{}
+			report.Errors++
+		} else {
+			c.After = evalItems(result.Docs, result.Skills)
+			c.Added, c.Removed = diffItems(c.Before, c.After)
+		}
+		if c.Changed() {
+			report.Changed++
+		}
+		report.Replayed++
+		report.Cases = append(report.Cases, c)
+	}
+
+	report.LatencyMS = time.Since(start).Milliseconds()
+	return report
+}
+
+// diffItems returns items only in after (added) and only in before (removed).
+func diffItems(before, after []string) (added, removed []string) {
+	in := func(list []string) map[string]bool {
+		m := make(map[string]bool, len(list))
+		for _, s := range list {
+			m[s] = true
+		}
+		return m
+	}
+	b, a := in(before), in(after)
+	for _, s := range after {
+		if !b[s] {
+			added = append(added, s)
+		}
+	}
+	for _, s := range before {
+		if !a[s] {
+			removed = append(removed, s)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
@@ -0,0 +1,55 @@
+package internal
+
+import (
+	"errors"
+	"testing"
+)
+
+func replayEntry(docs ...string) LogEntry {
+	return LogEntry{
+		Timestamp: "2026-03-01T10:00:00Z",
+		Status:    "ok",
+		Result:    &RouteResult{Docs: docs, Skills: []string{}},
+		Input: &RouteInput{
+			Messages: []Message{{Type: "user", Text: "how do we deploy the api service?"}},
+			Registry: Registry{
+				Docs:   []RegistryDoc{{Path: "docs/a.md", Summary: "a"}, {Path: "docs/b.md", Summary: "b"}},
+				Skills: []RegistrySkill{},
+			},
+		},
+	}
+}
+
+func TestReplay_DiffsDecisions(t *testing.T) {
+	cfg := DefaultConfig()
+	r := &Router{Config: cfg, Provider: StaticProvider{Response: `{"reasoning":"x","docs":["docs/b.md"],"skills":[]}`}}
+
+	entries := []LogEntry{
+		replayEntry("docs/a.md"),
+		replayEntry("docs/b.md"),
+		{Status: "ok", Result: &RouteResult{}}, // logged without input
+	}
+	report := Replay(entries, r)
+
+	if report.Replayed != 2 || report.Changed != 1 || report.NoInput != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	c := report.Cases[0]
+	if len(c.Added) != 1 || c.Added[0] != "docs/b.md" || len(c.Removed) != 1 || c.Removed[0] != "docs/a.md" {
+		t.Errorf("unexpected diff: added %v removed %v", c.Added, c.Removed)
+	}
+	if c.Message != "how do we deploy the api service?" {
+		t.Errorf("expected last user message, got %q", c.Message)
+	}
+	if report.Cases[1].Changed() {
+		t.Error("identical decision should not be reported as changed")
+	}
+}
+
+func TestReplay_ProviderError(t *testing.T) {
+	r := &Router{Config: DefaultConfig(), Provider: StaticProvider{Err: errors.New("boom")}}
+	report := Replay([]LogEntry{replayEntry("docs/a.md")}, r)
+	if report.Errors != 1 || report.Changed != 0 || report.Cases[0].Error == "" {
+		t.Errorf("expected one errored case, got %+v", report)
+	}
+}
@@ -0,0 +1,143 @@
+package internal
+
+import (
+	"strings"
+)
+
+// resolveRequires adds the prerequisites of every selected item, transitively,
+// placing each ahead of the item that needs it. Items the session already has,
+// or that are already selected, are not added again. Sections need what their
+// file needs. Requirements name a doc
+// path or a skill ("/name" forces a skill) in registry, the items left after
+// when conditions and package scopes; requirements only in full were filtered
+// out and are reported, not added. Returns the expanded result and one line per
+// resolved chain, cycle, filtered or unknown requirement, for the log.
+func resolveRequires(result *RouteResult, registry, full Registry, session SessionState) (*RouteResult, []string) {
+	docs := make(map[string]RegistryDoc, len(registry.Docs))
+	for _, d := range registry.Docs {
+		docs[d.Path] = d
+	}
+	skills := make(map[string]RegistrySkill, len(registry.Skills))
+	for _, s := range registry.Skills {
+		skills[s.Name] = s
+	}
+	covered := map[string]bool{}
+	for _, d := range session.docsGiven() {
+		covered["doc\x00"+d] = true
+	}
+	for _, s := range session.SkillsUsed {
+		covered["skill\x00"+s] = true
+	}
+	for _, it := range result.Items {
+		covered[it.Kind+"\x00"+it.Name] = true
+	}
+	has := func(kind, name string) bool {
+		if kind == "doc" {
+			file, _ := splitDocRef(name)
+			return covered["doc\x00"+name] || covered["doc\x00"+file]
+		}
+		return covered["skill\x00"+name]
+	}
+	requiresOf := func(it RankedItem) []string {
+		switch it.Kind {
+		case "doc":
+			// A section needs what its file needs
+			file, _ := splitDocRef(it.Name)
+			if file != it.Name {
+				return union(docs[it.Name].Requires, docs[file].Requires)
+			}
+			return docs[it.Name].Requires
+		case "skill":
+			return skills[it.Name].Requires
+		}
+		return nil
+	}
+	lookup := func(ref string) (RankedItem, bool) {
+		if name, ok := strings.CutPrefix(ref, "/"); ok {
+			_, found := skills[name]
+			return RankedItem{Kind: "skill", Name: name}, found
+		}
+		if _, ok := docs[ref]; ok {
+			return RankedItem{Kind: "doc", Name: ref}, true
+		}
+		_, found := skills[ref]
+		return RankedItem{Kind: "skill", Name: ref}, found
+	}
+
+	var log []string
+	var items []RankedItem
+	// visit appends its unmet prerequisites depth-first; chain holds the path
+	// from the selected item, to detect cycles and describe what was added.
+	var visit func(it RankedItem, chain []string)
+	visit = func(it RankedItem, chain []string) {
+		for _, ref := range requiresOf(it) {
+			req, ok := lookup(ref)
+			if !ok {
+				reason := " (not in registry)"
+				if inRegistry(full, ref) {
+					reason = " (filtered out by when or package scope)"
+				}
+				log = append(log, strings.Join(chain, " -> ")+" -> "+ref+reason)
+				continue
+			}
+			if inChain(chain, req.Name) {
+				log = append(log, "cycle: "+strings.Join(chain, " -> ")+" -> "+req.Name)
+				continue
+			}
+			if has(req.Kind, req.Name) {
+				continue
+			}
+			covered[req.Kind+"\x00"+req.Name] = true
+			next := append(append([]string{}, chain...), req.Name)
+			visit(req, next)
+			req.Score = it.Score
+			req.Reason = "required by " + it.Name
+			req.Source = "required"
+			items = append(items, req)
+			log = append(log, strings.Join(next, " -> "))
+		}
+	}
+	for _, it := range result.Items {
+		visit(it, []string{it.Name})
+		items = append(items, it)
+	}
+	if len(items) == len(result.Items) {
+		return result, log
+	}
+
+	out := &RouteResult{Reasoning: result.Reasoning, Docs: []string{}, Skills: []string{}, Items: items, Content: result.Content}
+	for _, it := range items {
+		switch it.Kind {
+		case "doc":
+			out.Docs = append(out.Docs, it.Name)
+		case "skill":
+			out.Skills = append(out.Skills, it.Name)
+		}
+	}
+	return out, log
+}
+
+func inChain(chain []string, name string) bool {
+	for _, c := range chain {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// inRegistry reports whether a requirement names a doc or skill in registry.
+func inRegistry(registry Registry, ref string) bool {
+	name, skill := strings.CutPrefix(ref, "/")
+	for _, d := range registry.Docs {
+		if !skill && d.Path == name {
+			return true
+		}
+	}
+	for _, sk := range registry.Skills {
+		if sk.Name == name {
+			return true
+		}
+	}
+	return false
+}
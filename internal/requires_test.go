@@ -0,0 +1,115 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func requiresRegistry() Registry {
+	return Registry{
+		Docs: []RegistryDoc{
+			{Path: "payments/refunds.md", Requires: []string{"payments/overview.md", "/ledger"}},
+			{Path: "payments/overview.md", Requires: []string{"docs/money.md"}},
+			{Path: "docs/money.md"},
+			{Path: "docs/a.md", Requires: []string{"docs/b.md"}},
+			{Path: "docs/b.md", Requires: []string{"docs/a.md", "docs/missing.md"}},
+		},
+		Skills: []RegistrySkill{{Name: "ledger"}},
+	}
+}
+
+func TestResolveRequires_Transitive(t *testing.T) {
+	result := rankResult(RouteResult{Items: []RankedItem{{Kind: "doc", Name: "payments/refunds.md", Score: 0.8}}}, RankingConfig{})
+
+	got, log := resolveRequires(result, requiresRegistry(), requiresRegistry(), SessionState{})
+
+	if strings.Join(got.Docs, ",") != "docs/money.md,payments/overview.md,payments/refunds.md" {
+		t.Errorf("expected prerequisites first, got %v", got.Docs)
+	}
+	if len(got.Skills) != 1 || got.Skills[0] != "ledger" {
+		t.Errorf("expected required skill, got %v", got.Skills)
+	}
+	if !strings.Contains(strings.Join(log, "\n"), "payments/refunds.md -> payments/overview.md -> docs/money.md") {
+		t.Errorf("expected the chain in the log, got %v", log)
+	}
+}
+
+func TestResolveRequires_SkipsSessionItems(t *testing.T) {
+	result := rankResult(RouteResult{Docs: []string{"payments/refunds.md"}}, RankingConfig{})
+	session := SessionState{DocsRead: []string{"payments/overview.md"}, SkillsUsed: []string{"ledger"}}
+
+	got, _ := resolveRequires(result, requiresRegistry(), requiresRegistry(), session)
+
+	if strings.Join(got.Docs, ",") != "payments/refunds.md" || len(got.Skills) != 0 {
+		t.Errorf("already-read prerequisites should not be added, got %v %v", got.Docs, got.Skills)
+	}
+}
+
+func TestResolveRequires_Cycle(t *testing.T) {
+	result := rankResult(RouteResult{Docs: []string{"docs/a.md"}}, RankingConfig{})
+
+	got, log := resolveRequires(result, requiresRegistry(), requiresRegistry(), SessionState{})
+
+	if strings.Join(got.Docs, ",") != "docs/b.md,docs/a.md" {
+		t.Errorf("expected b then a, got %v", got.Docs)
+	}
+	joined := strings.Join(log, "\n")
+	if !strings.Contains(joined, "cycle: docs/a.md -> docs/b.md -> docs/a.md") || !strings.Contains(joined, "docs/missing.md (not in registry)") {
+		t.Errorf("expected cycle and unknown requirement in the log, got %v", log)
+	}
+}
+
+func TestResolveRequires_Section(t *testing.T) {
+	registry := requiresRegistry()
+	registry.Docs = append(registry.Docs, RegistryDoc{Path: "payments/refunds.md#partial"})
+	result := rankResult(RouteResult{Docs: []string{"payments/refunds.md#partial"}}, RankingConfig{})
+
+	got, _ := resolveRequires(result, registry, registry, SessionState{})
+
+	if strings.Join(got.Docs, ",") != "docs/money.md,payments/overview.md,payments/refunds.md#partial" || len(got.Skills) != 1 {
+		t.Errorf("a section should bring its file's prerequisites, got %v %v", got.Docs, got.Skills)
+	}
+}
+
+func TestRoute_PrerequisitesFollowRegistryRules(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	registry := requiresRegistry()
+	registry.Docs[1].SkipWhen = []string{"quick question"}
+	r := &Router{Config: DefaultConfig(), Provider: StaticProvider{Response: `{"items":[{"kind":"doc","name":"payments/refunds.md","score":0.9}]}`}}
+
+	result, info, err := r.Route(RouteInput{Messages: []Message{{Type: "user", Text: "quick question about refunds"}}, Registry: registry})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(result.Docs, ",") != "docs/money.md,payments/refunds.md" {
+		t.Errorf("a prerequisite matching skip_when should be dropped, got %v", result.Docs)
+	}
+	if len(info.Dropped) != 1 || !strings.HasPrefix(info.Dropped[0], "payments/overview.md") {
+		t.Errorf("expected the dropped prerequisite logged, got %v", info.Dropped)
+	}
+}
+
+func TestRoute_PrerequisitesRespectFilters(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	registry := requiresRegistry()
+	registry.Docs[1].When = map[string][]string{MetaBranch: {"release/*"}}
+	registry.Docs[0].Requires = append(registry.Docs[0].Requires, "services/web/ui.md")
+	registry.Docs = append(registry.Docs, RegistryDoc{Path: "services/web/ui.md", Scope: "services/web"})
+	r := &Router{Config: DefaultConfig(), Root: "/repo", Provider: StaticProvider{Response: `{"items":[{"kind":"doc","name":"payments/refunds.md","score":0.9}]}`}}
+
+	result, info, err := r.Route(RouteInput{
+		Messages: []Message{{Type: "user", Text: "refund a payment"}},
+		Registry: registry,
+		Metadata: map[string]any{MetaBranch: "main", MetaCWD: "/repo/services/api"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(result.Docs, ",") != "payments/refunds.md" {
+		t.Errorf("filtered prerequisites should not be injected, got %v", result.Docs)
+	}
+	joined := strings.Join(info.Requires, "\n")
+	if !strings.Contains(joined, "payments/overview.md (filtered out") || !strings.Contains(joined, "services/web/ui.md (filtered out") {
+		t.Errorf("expected filtered requirements reported, got %v", info.Requires)
+	}
+}
@@ -4,103 +4,257 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
 	"strings"
-
-	"github.com/openai/openai-go"
-	"github.com/openai/openai-go/option"
-	"github.com/openai/openai-go/responses"
-	"github.com/openai/openai-go/shared"
 )
 
-// Route decides what docs and skills to inject for the given input.
-// Returns the result, the excluded registry, the prompt, the raw LLM response, a skip reason, and any error.
-// skip reason is non-empty when the LLM was not called (empty registry after filtering).
-func Route(input RouteInput, cfg *Config) (*RouteResult, Registry, string, string, string, error) {
-	empty := &RouteResult{Docs: []string{}, Skills: []string{}}
-	noExcluded := Registry{Docs: []RegistryDoc{}, Skills: []RegistrySkill{}}
+// RouteInfo describes how a routing decision was made, for logging.
+type RouteInfo struct {
+	Excluded      Registry // items filtered out because they were already used this session
+	Prompt        string
+	RawResponse   string
+	SkipReason    string         // non-empty when the LLM was not called
+	Cached        bool           // result came from the decision cache
+	Redactions    map[string]int // secrets/PII removed from messages before prompting, by detector
+	PromptVersion string         // template version the decision was (or would have been) made with
+	Dropped       []string       // docs removed by skip_when or conflicts_with, with the reason
+	Requires      []string       // prerequisite chains added, plus cycles and unknown requirements
+	Warnings      []string       // problems with the input that didn't stop routing, e.g. unknown metadata keys
+}
+
+// Router routes conversations using a config, a store for cached decisions, and a model provider.
+type Router struct {
+	Config   *Config
+	Store    Store    // nil disables the decision cache
+	Provider Provider // nil: built from Config on first LLM call
+	Root     string   // project root that registry paths and metadata.files are relative to
+}
 
-	if len(input.Registry.Docs) == 0 && len(input.Registry.Skills) == 0 {
-		return empty, noExcluded, "", "", "no docs or skills in registry", nil
+// Route decides what docs and skills to inject for the given input, using the
+// configured storage backend for the decision cache. See Router.Route.
+func Route(input RouteInput, cfg *Config) (*RouteResult, RouteInfo, error) {
+	r := &Router{Config: cfg}
+	if store, err := OpenStore(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "[reflex] storage error, cache disabled: %v\n", err)
+	} else {
+		defer store.Close()
+		r.Store = store
 	}
+	return r.Route(input)
+}
+
+// Route decides what docs, skills, and other items to inject for the given input.
+// Returns the result, details about how it was reached, and any error.
+// info.SkipReason is non-empty when the LLM was not called. Items added without
+// the LLM (see prepare) come first, even if routing was skipped or failed; then
+// prerequisites and registry rules apply to everything selected.
+func (r *Router) Route(input RouteInput) (*RouteResult, RouteInfo, error) {
+	p := r.prepare(input)
+	result, info, err := r.route(p)
+	info.Warnings = p.warnings
+	// Pinned items don't depend on the model, so they survive its failures too
+	result, info.Requires = resolveRequires(withPinned(result, p.pinned), p.available, p.full, input.Session)
+	// Prerequisites and pinned items are held to skip_when and conflicts_with too
+	result, info.Dropped = applyRegistryRules(result, p.full, p.input.Messages)
+	annotateStale(result, p.full)
+	result.ByKind = groupByKind(result.Items)
+	return result, info, err
+}
+
+// preparedInput is a route input narrowed down to what the model is asked about.
+type preparedInput struct {
+	input      RouteInput     // Registry holds what's left after pinned and file-scoped items; Messages are redacted
+	full       Registry       // normalized registry, for registry rules and stale flags
+	available  Registry       // full minus items failing when conditions or package scopes
+	pinned     []RankedItem   // reminders, pinned, and file-scoped items added without the model
+	offered    Registry       // input.Registry minus items already used this session
+	metadata   map[string]any // input.Metadata, redacted for the prompt
+	redactions map[string]int // by detector
+	warnings   []string
+}
 
-	// Filter registry: remove items already used this session
-	registry := filterRegistry(input.Registry, input.Session)
-	excluded := excludedRegistry(input.Registry, registry)
-	if len(registry.Docs) == 0 && len(registry.Skills) == 0 {
-		n := len(input.Registry.Docs) + len(input.Registry.Skills)
-		return empty, excluded, "", "", fmt.Sprintf("all %d item(s) already injected this session", n), nil
+// prepare normalizes the registry, applies when conditions and package scopes,
+// takes out reminders, pinned and file-scoped items, filters items already used
+// this session, and redacts messages and metadata. Route and RenderPrompt both start here,
+// so `reflex prompt` shows exactly what `reflex route` sends.
+func (r *Router) prepare(input RouteInput) preparedInput {
+	registry, warnings := normalizeRegistry(input.Registry)
+	p := preparedInput{full: registry, warnings: append(warnings, ValidateMetadata(input.Metadata)...)}
+	p.available = r.nearby(filterWhen(registry, input.Metadata, r.Root), input.Metadata)
+	if r.Config.Compliance.Reinject {
+		p.pinned = reminders(p.available, input.Session)
+	}
+	due, rest := splitPinned(p.available, input.Session)
+	p.pinned = append(p.pinned, due...)
+	if r.filesMode() == FilesInclude {
+		var scoped []RankedItem
+		scoped, rest = splitScoped(rest, input.Session, metadataFiles(input.Metadata, r.Root))
+		p.pinned = append(p.pinned, scoped...)
 	}
+	input.Registry = rest
+	p.offered = filterRegistry(rest, input.Session)
 
-	// Build prompt
-	prompt := Build(input.Messages, registry)
+	// Scrub secrets and PII before the conversation is sent anywhere
+	counts := map[string]int{}
+	redactor := NewRedactor(r.Config.Redaction)
+	input.Messages = redactor.RedactMessages(input.Messages, counts)
+	p.metadata = redactor.RedactMetadata(input.Metadata, counts)
+	p.redactions = mergeCounts(nil, counts)
+	p.input = input
+	return p
+}
+
+// nearby applies scopes.mode filter: docs and items of packages away from the
+// agent's metadata.cwd are left out.
+func (r *Router) nearby(registry Registry, metadata map[string]any) Registry {
+	if r.scopesMode() != ScopesFilter {
+		return registry
+	}
+	return filterPackages(registry, agentCWD(metadata, r.Root))
+}
 
-	// Get API key: env var takes priority, then stored key
-	apiKey := ResolveAPIKey(cfg)
-	if apiKey == "" {
-		return empty, noExcluded, "", "", "", fmt.Errorf("no API key configured. Run: reflex config set api-key <your-key>")
+// scopesMode returns the configured scopes.mode, defaulting to filter.
+func (r *Router) scopesMode() string {
+	if r.Config.Scopes.Mode == "" {
+		return ScopesFilter
 	}
+	return r.Config.Scopes.Mode
+}
 
-	// Call LLM
-	client := openai.NewClient(
-		option.WithAPIKey(apiKey),
-		option.WithBaseURL(cfg.Provider.BaseURL),
-	)
-
-	var raw string
-
-	useResponsesAPI := cfg.Provider.ResponsesAPI || strings.Contains(cfg.Provider.BaseURL, "api.openai.com")
-	if useResponsesAPI {
-		resp, err := client.Responses.New(context.Background(), responses.ResponseNewParams{
-			Model: shared.ResponsesModel(cfg.Provider.Model),
-			Input: responses.ResponseNewParamsInputUnion{
-				OfString: openai.String(prompt),
-			},
-			Reasoning: shared.ReasoningParam{
-				Effort: shared.ReasoningEffortMedium,
-			},
-		})
-		if err != nil {
-			return empty, excluded, prompt, "", "", fmt.Errorf("LLM error: %w", err)
+// filesMode returns the configured files.mode, defaulting to include.
+func (r *Router) filesMode() string {
+	if r.Config.Files.Mode == "" {
+		return FilesInclude
+	}
+	return r.Config.Files.Mode
+}
+
+// ranked drops unlisted selections, then applies file boosts, package
+// penalties, and ranking settings to a decision, in that order.
+func (r *Router) ranked(decision RouteResult, registry Registry, input RouteInput) *RouteResult {
+	decision = listedItems(decision, registry)
+	if r.filesMode() == FilesBoost {
+		decision = boostScoped(decision, registry, metadataFiles(input.Metadata, r.Root))
+	}
+	if r.scopesMode() == ScopesBoost {
+		decision = penalizeDistant(decision, registry, agentCWD(input.Metadata, r.Root))
+	}
+	return rankResult(decision, r.Config.Ranking)
+}
+
+func (r *Router) route(p preparedInput) (*RouteResult, RouteInfo, error) {
+	cfg, input, registry := r.Config, p.input, p.offered
+	empty := &RouteResult{Docs: []string{}, Skills: []string{}, Items: []RankedItem{}}
+	info := RouteInfo{Excluded: Registry{Docs: []RegistryDoc{}, Skills: []RegistrySkill{}}}
+
+	tmpl, err := LoadPromptTemplate(cfg.Prompt)
+	if err != nil {
+		return empty, info, err
+	}
+	info.PromptVersion = tmpl.Version
+
+	if input.Registry.Len() == 0 {
+		info.SkipReason = "no docs or skills in registry"
+		return empty, info, nil
+	}
+
+	// Items already used this session were filtered by prepare
+	info.Excluded = excludedRegistry(input.Registry, registry)
+	if registry.Len() == 0 {
+		n := input.Registry.Len()
+		info.SkipReason = fmt.Sprintf("all %d item(s) already injected this session", n)
+		return empty, info, nil
+	}
+	info.Redactions = p.redactions
+
+	// Skip acknowledgements like "thanks" or "ok" without calling the LLM
+	if reason := classifyTrivial(input.Messages, cfg.Skip); reason != "" {
+		info.SkipReason = reason
+		return empty, info, nil
+	}
+
+	// Serve retries and repeated questions from the local cache
+	data := r.promptData(p)
+	key := cacheKey(data, cfg.Provider.Model, tmpl, cfg.Cache.KeyMessages)
+	if r.Store != nil {
+		if cached := r.Store.CacheGet(key); cached != nil {
+			info.Cached = true
+			return r.ranked(*cached, registry, input), info, nil
 		}
-		raw = strings.TrimSpace(resp.OutputText())
-	} else {
-		resp, err := client.Chat.Completions.New(context.Background(), openai.ChatCompletionNewParams{
-			Model: openai.ChatModel(cfg.Provider.Model),
-			Messages: []openai.ChatCompletionMessageParamUnion{
-				openai.UserMessage(prompt),
-			},
-		})
+	}
+
+	// Build prompt
+	prompt, err := tmpl.Render(data)
+	if err != nil {
+		return empty, info, err
+	}
+	info.Prompt = prompt
+
+	// Call LLM
+	provider := r.Provider
+	if provider == nil {
+		p, err := NewProvider(cfg)
 		if err != nil {
-			return empty, excluded, prompt, "", "", fmt.Errorf("LLM error: %w", err)
+			return empty, info, err
 		}
-		if len(resp.Choices) == 0 {
-			return empty, excluded, prompt, "", "", fmt.Errorf("LLM returned no choices")
-		}
-		raw = strings.TrimSpace(resp.Choices[0].Message.Content)
+		provider = p
+	}
+	raw, err := provider.Complete(context.Background(), prompt)
+	if err != nil {
+		return empty, info, err
 	}
+	raw = strings.TrimSpace(raw)
 
 	if raw == "" {
-		return empty, excluded, prompt, "", "", fmt.Errorf("LLM returned empty response")
+		return empty, info, fmt.Errorf("LLM returned empty response")
 	}
+	info.RawResponse = raw
 
 	// Strip markdown fences if present
 	cleaned := stripFences(raw)
 
-	// Parse response
-	var result RouteResult
-	if err := json.Unmarshal([]byte(cleaned), &result); err != nil {
-		return empty, excluded, prompt, raw, "", fmt.Errorf("failed to parse LLM response: %w", err)
+	// Parse response: ranked items, or the older flat docs/skills arrays
+	var parsed RouteResult
+	if err := json.Unmarshal([]byte(cleaned), &parsed); err != nil {
+		return empty, info, fmt.Errorf("failed to parse LLM response: %w", err)
 	}
 
-	// Ensure non-nil slices
-	if result.Docs == nil {
-		result.Docs = []string{}
+	// Cache every ranked item so ranking settings apply to hits as well
+	all := rankResult(parsed, RankingConfig{})
+	if r.Store != nil {
+		r.Store.CachePut(key, cfg.Provider.Model, all)
 	}
-	if result.Skills == nil {
-		result.Skills = []string{}
+
+	return r.ranked(*all, registry, input), info, nil
+}
+
+// RenderPrompt returns the exact prompt Route would send for input, without
+// skipping, caching, or calling the model.
+func (r *Router) RenderPrompt(input RouteInput) (string, RouteInfo, error) {
+	p := r.prepare(input)
+	info := RouteInfo{
+		Excluded:   excludedRegistry(p.input.Registry, p.offered),
+		Redactions: p.redactions,
+		Warnings:   p.warnings,
+	}
+	tmpl, err := LoadPromptTemplate(r.Config.Prompt)
+	if err != nil {
+		return "", info, err
 	}
+	info.PromptVersion = tmpl.Version
+	prompt, err := tmpl.Render(r.promptData(p))
+	info.Prompt = prompt
+	return prompt, info, err
+}
 
-	return &result, excluded, prompt, raw, "", nil
+func (r *Router) promptData(p preparedInput) PromptData {
+	return PromptData{
+		Registry: p.offered,
+		Messages: p.input.Messages,
+		Session:  p.input.Session,
+		Metadata: p.metadata,
+		Context:  metadataContext(p.metadata, r.Root),
+	}
 }
 
 // excludedRegistry returns items in full that are not in filtered.
@@ -126,13 +280,25 @@ func excludedRegistry(full, filtered Registry) Registry {
 			skills = append(skills, s)
 		}
 	}
-	return Registry{Docs: docs, Skills: skills}
+	filteredItems := make(map[string]bool, len(filtered.Items))
+	for _, it := range filtered.Items {
+		filteredItems[itemKey(it.Kind, it.ID)] = true
+	}
+	var items []RegistryItem
+	for _, it := range full.Items {
+		if !filteredItems[itemKey(it.Kind, it.ID)] {
+			items = append(items, it)
+		}
+	}
+	return Registry{Docs: docs, Skills: skills, Items: items}
 }
 
-// filterRegistry removes items already read/used this session.
+// filterRegistry removes items already given or used this session. Sections
+// ("path#anchor") are removed when the section or its whole file was read.
 func filterRegistry(registry Registry, session SessionState) Registry {
-	readSet := make(map[string]bool, len(session.DocsRead))
-	for _, d := range session.DocsRead {
+	given := session.docsGiven()
+	readSet := make(map[string]bool, len(given))
+	for _, d := range given {
 		readSet[d] = true
 	}
 	usedSet := make(map[string]bool, len(session.SkillsUsed))
@@ -142,7 +308,9 @@ func filterRegistry(registry Registry, session SessionState) Registry {
 
 	docs := []RegistryDoc{}
 	for _, doc := range registry.Docs {
-		if !readSet[doc.Path] {
+		// A section is covered once it, or the whole file, has been read
+		file, _ := splitDocRef(doc.Path)
+		if !readSet[doc.Path] && !readSet[file] {
 			docs = append(docs, doc)
 		}
 	}
@@ -152,7 +320,17 @@ func filterRegistry(registry Registry, session SessionState) Registry {
 			skills = append(skills, skill)
 		}
 	}
-	return Registry{Docs: docs, Skills: skills}
+	itemsUsed := make(map[string]bool, len(session.ItemsUsed))
+	for _, key := range session.ItemsUsed {
+		itemsUsed[key] = true
+	}
+	var items []RegistryItem
+	for _, it := range registry.Items {
+		if !itemsUsed[itemKey(it.Kind, it.ID)] {
+			items = append(items, it)
+		}
+	}
+	return Registry{Docs: docs, Skills: skills, Items: items}
 }
 
 // stripFences removes markdown code fences from LLM output.
@@ -1,6 +1,9 @@
 package internal
 
 import (
+	"flag"
+	"os"
+	"strings"
 	"testing"
 )
 
@@ -119,13 +122,13 @@ func TestRoute_EmptyRegistryReturnsSkipReason(t *testing.T) {
 		Session:  SessionState{},
 	}
 
-	result, _, _, _, skipReason, err := Route(input, DefaultConfig())
+	result, info, err := Route(input, DefaultConfig())
 
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if skipReason != "no docs or skills in registry" {
-		t.Errorf("expected skip reason 'no docs or skills in registry', got %q", skipReason)
+	if info.SkipReason != "no docs or skills in registry" {
+		t.Errorf("expected skip reason 'no docs or skills in registry', got %q", info.SkipReason)
 	}
 	if len(result.Docs) != 0 || len(result.Skills) != 0 {
 		t.Errorf("expected empty result, got docs=%v skills=%v", result.Docs, result.Skills)
@@ -145,15 +148,165 @@ func TestRoute_AllItemsAlreadyInjectedReturnsSkipReason(t *testing.T) {
 		},
 	}
 
-	result, _, _, _, skipReason, err := Route(input, DefaultConfig())
+	result, info, err := Route(input, DefaultConfig())
 
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if skipReason != "all 2 item(s) already injected this session" {
-		t.Errorf("expected skip reason about 2 items, got %q", skipReason)
+	if info.SkipReason != "all 2 item(s) already injected this session" {
+		t.Errorf("expected skip reason about 2 items, got %q", info.SkipReason)
 	}
 	if len(result.Docs) != 0 || len(result.Skills) != 0 {
 		t.Errorf("expected empty result, got docs=%v skills=%v", result.Docs, result.Skills)
 	}
 }
+
+func TestRouter_ParsesProviderResponse(t *testing.T) {
+	r := &Router{
+		Config:   DefaultConfig(),
+		Provider: StaticProvider{Response: "```json\n{\"reasoning\": \"auth\", \"docs\": [\"docs/a.md\"]}\n```"},
+	}
+	input := RouteInput{
+		Messages: []Message{{Type: "user", Text: "how does login work?"}},
+		Registry: Registry{Docs: []RegistryDoc{{Path: "docs/a.md", Summary: "auth"}}},
+	}
+
+	result, info, err := r.Route(input)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Docs) != 1 || result.Docs[0] != "docs/a.md" {
+		t.Errorf("expected docs/a.md, got %v", result.Docs)
+	}
+	if result.Skills == nil {
+		t.Error("skills should be a non-nil empty slice")
+	}
+	if info.Prompt == "" || info.RawResponse == "" {
+		t.Error("info should carry the prompt and raw response")
+	}
+}
+
+func TestRenderPrompt_MatchesRoute(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg := DefaultConfig()
+	cfg.Compliance.Reinject = true
+	r := &Router{Config: cfg, Provider: StaticProvider{Response: `{"items":[]}`}}
+	input := RouteInput{
+		Messages: []Message{{Type: "user", Text: "deploy with key sk-abcdefghijklmnopqrstuvwx"}},
+		Registry: Registry{Docs: []RegistryDoc{
+			{Path: "docs/always.md", Summary: "always", Inject: "always"},
+			{Path: "docs/ignored.md", Summary: "ignored"},
+			{Path: "docs/deploy.md", Summary: "deploy"},
+			{Path: "docs/main.md", Summary: "main only", When: map[string][]string{MetaBranch: {"main"}}},
+		}},
+		Metadata: map[string]any{"branch": "feature"},
+		Session:  SessionState{Ignored: []string{"docs/ignored.md"}},
+	}
+
+	_, routed, err := r.Route(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prompt, rendered, err := r.RenderPrompt(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prompt != routed.Prompt {
+		t.Errorf("reflex prompt should show what route sends:\n%s\n---\n%s", prompt, routed.Prompt)
+	}
+	if rendered.Redactions["api_key"] != 1 || len(rendered.Excluded.Docs) != 1 {
+		t.Errorf("unexpected info: %+v", rendered)
+	}
+}
+
+var updateFixtures = flag.Bool("update", false, "re-record testdata/fixtures from the canned responses in tests")
+
+// TestRoute_EndToEndPlayback runs the full routing path against recorded
+// fixtures. A prompt change invalidates them; re-record with
+// `go test ./internal -run EndToEnd -update`.
+func TestRoute_EndToEndPlayback(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg := DefaultConfig()
+	cfg.Cache.Disabled = true
+	cfg.Provider.Mode = ModePlayback
+	cfg.Provider.FixtureDir = "testdata/fixtures"
+
+	registry := Registry{
+		Docs: []RegistryDoc{
+			{Path: "docs/auth.md", Summary: "OAuth and session handling", ReadWhen: []string{"login", "OAuth"}},
+			{Path: "docs/deploy.md", Summary: "Deploying to production", ReadWhen: []string{"deploy", "release"}},
+		},
+		Skills: []RegistrySkill{{Name: "release", Description: "Cut and publish a release"}},
+	}
+	cases := []struct {
+		message  string
+		response string
+		docs     []string
+		skills   []string
+	}{
+		{"how does the OAuth login flow refresh tokens?", `{"reasoning":"auth question","items":[{"kind":"doc","name":"docs/auth.md","score":0.95,"reason":"token refresh"},{"kind":"doc","name":"docs/deploy.md","score":0.2}]}`, []string{"docs/auth.md"}, nil},
+		{"let's ship version 2.1 to production", "```json\n{\"reasoning\":\"release\",\"items\":[{\"kind\":\"skill\",\"name\":\"release\",\"score\":0.9},{\"kind\":\"doc\",\"name\":\"docs/deploy.md\",\"score\":0.8}]}\n```", []string{"docs/deploy.md"}, []string{"release"}},
+		{"rename this variable to something clearer", `{"reasoning":"nothing relevant","docs":[],"skills":[]}`, nil, nil},
+	}
+
+	cfg.Ranking.MinScore = 0.5
+	if *updateFixtures {
+		os.RemoveAll(cfg.Provider.FixtureDir)
+	}
+	for _, c := range cases {
+		r := &Router{Config: cfg}
+		if *updateFixtures {
+			r.Provider = RecordingProvider{Provider: StaticProvider{Response: c.response}, Dir: cfg.Provider.FixtureDir}
+		}
+		result, _, err := r.Route(RouteInput{
+			Messages: []Message{{Type: "user", Text: c.message}},
+			Registry: registry,
+		})
+		if err != nil {
+			t.Fatalf("%q: %v", c.message, err)
+		}
+		if strings.Join(result.Docs, ",") != strings.Join(c.docs, ",") || strings.Join(result.Skills, ",") != strings.Join(c.skills, ",") {
+			t.Errorf("%q: got docs %v skills %v", c.message, result.Docs, result.Skills)
+		}
+	}
+}
+
+func TestRankResult_SortsFiltersAndCaps(t *testing.T) {
+	r := RouteResult{
+		Items: []RankedItem{
+			{Kind: "doc", Name: "docs/b.md", Score: 0.6},
+			{Kind: "doc", Name: "docs/a.md", Score: 0.9},
+			{Kind: "doc", Name: "docs/a.md", Score: 0.4}, // duplicate, lower score
+			{Kind: "doc", Name: "docs/c.md", Score: 0.3},
+			{Kind: "skill", Name: "deploy", Score: 1.7},
+			{Kind: "widget", Name: "x", Score: 1},
+		},
+		Skills: []string{"deploy", "test"}, // legacy entries merge in
+	}
+
+	got := rankResult(r, RankingConfig{MinScore: 0.5, MaxDocs: 1})
+
+	if strings.Join(got.Docs, ",") != "docs/a.md" {
+		t.Errorf("expected the top doc only, got %v", got.Docs)
+	}
+	if strings.Join(got.Skills, ",") != "deploy,test" {
+		t.Errorf("expected both skills, got %v", got.Skills)
+	}
+	if len(got.Items) != 4 || got.Items[0].Score != 1 || got.Items[0].Name != "deploy" {
+		t.Errorf("expected clamped, score-sorted items, got %+v", got.Items)
+	}
+	if got.Items[1].Kind != "widget" {
+		t.Errorf("items of other kinds should be kept in items only, got %+v", got.Items)
+	}
+}
+
+func TestRankResult_LegacyArrays(t *testing.T) {
+	got := rankResult(RouteResult{Docs: []string{"docs/a.md"}}, RankingConfig{MinScore: 0.8})
+	if len(got.Items) != 1 || got.Items[0].Kind != "doc" || got.Items[0].Score != legacyScore {
+		t.Errorf("legacy docs should become certain items, got %+v", got.Items)
+	}
+	if got.Skills == nil || got.Items == nil {
+		t.Error("result slices should be non-nil")
+	}
+}
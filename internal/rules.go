@@ -0,0 +1,82 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+)
+
+// applyRegistryRules enforces registry constraints the model may ignore:
+// a doc is dropped when one of its skip_when hints appears in the latest user
+// message, or when it conflicts with a higher-ranked doc already kept.
+// Sections follow the rules of their file, and conflicts cover every section
+// of the conflicting file. Returns the filtered result and a description of
+// each dropped doc.
+func applyRegistryRules(result *RouteResult, registry Registry, messages []Message) (*RouteResult, []string) {
+	docs := make(map[string]RegistryDoc, len(registry.Docs))
+	for _, d := range registry.Docs {
+		docs[d.Path] = d
+	}
+	text, _ := lastUserText(messages)
+	msg := " " + normalizeMessage(text) + " "
+
+	var dropped []string
+	kept := map[string]RegistryDoc{} // by file
+	out := &RouteResult{Reasoning: result.Reasoning, Docs: []string{}, Skills: result.Skills, Items: []RankedItem{}, Content: result.Content}
+	for _, it := range result.Items {
+		if it.Kind != "doc" {
+			out.Items = append(out.Items, it)
+			continue
+		}
+		d := docRules(docs, it.Name)
+		if reason := docRuleViolation(d, msg, kept); reason != "" {
+			dropped = append(dropped, fmt.Sprintf("%s: %s", it.Name, reason))
+			continue
+		}
+		file, _ := splitDocRef(it.Name)
+		kept[file] = d
+		out.Items = append(out.Items, it)
+		out.Docs = append(out.Docs, it.Name)
+	}
+	return out, dropped
+}
+
+// docRules returns the registry entry for ref with the skip_when and
+// conflicts_with of its file added, for sections.
+func docRules(docs map[string]RegistryDoc, ref string) RegistryDoc {
+	d, ok := docs[ref]
+	if !ok {
+		d = RegistryDoc{Path: ref}
+	}
+	if file, anchor := splitDocRef(ref); anchor != "" {
+		d.SkipWhen = union(d.SkipWhen, docs[file].SkipWhen)
+		d.ConflictsWith = union(d.ConflictsWith, docs[file].ConflictsWith)
+	}
+	return d
+}
+
+// docRuleViolation explains why d must not be returned given the docs kept so
+// far (by file), or returns "".
+func docRuleViolation(d RegistryDoc, msg string, kept map[string]RegistryDoc) string {
+	for _, hint := range d.SkipWhen {
+		if h := normalizeMessage(hint); h != "" && strings.Contains(msg, " "+h+" ") {
+			return fmt.Sprintf("skip_when %q matches the message", hint)
+		}
+	}
+	file, _ := splitDocRef(d.Path)
+	for _, other := range d.ConflictsWith {
+		if f, _ := splitDocRef(other); f != file {
+			if k, ok := kept[f]; ok {
+				return "conflicts with " + k.Path
+			}
+		}
+	}
+	// Conflicts are symmetric: honour ones declared only on the other doc
+	for f, k := range kept {
+		for _, other := range k.ConflictsWith {
+			if o, _ := splitDocRef(other); o == file && f != file {
+				return "conflicts with " + k.Path
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,99 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplyRegistryRules_SkipWhen(t *testing.T) {
+	registry := Registry{Docs: []RegistryDoc{
+		{Path: "docs/deploy.md", SkipWhen: []string{"prod logs", "read-only"}},
+		{Path: "docs/logs.md"},
+	}}
+	result := rankResult(RouteResult{Docs: []string{"docs/deploy.md", "docs/logs.md"}}, RankingConfig{})
+	messages := []Message{{Type: "user", Text: "Why are the Prod Logs so noisy?"}}
+
+	got, dropped := applyRegistryRules(result, registry, messages)
+
+	if strings.Join(got.Docs, ",") != "docs/logs.md" || len(got.Items) != 1 {
+		t.Errorf("expected deploy doc dropped, got %v", got.Docs)
+	}
+	if len(dropped) != 1 || !strings.Contains(dropped[0], "prod logs") {
+		t.Errorf("expected a skip_when explanation, got %v", dropped)
+	}
+
+	// Hints match whole words only
+	got, _ = applyRegistryRules(result, registry, []Message{{Type: "user", Text: "deploy the prod logsink"}})
+	if len(got.Docs) != 2 {
+		t.Errorf("partial-word match should not drop docs, got %v", got.Docs)
+	}
+}
+
+func TestApplyRegistryRules_ConflictsKeepHigherScore(t *testing.T) {
+	registry := Registry{Docs: []RegistryDoc{
+		{Path: "docs/api-v1.md", ConflictsWith: []string{"docs/api-v2.md"}},
+		{Path: "docs/api-v2.md"},
+	}}
+	result := rankResult(RouteResult{Items: []RankedItem{
+		{Kind: "doc", Name: "docs/api-v1.md", Score: 0.6},
+		{Kind: "doc", Name: "docs/api-v2.md", Score: 0.9},
+		{Kind: "skill", Name: "deploy", Score: 0.8},
+	}}, RankingConfig{})
+
+	got, dropped := applyRegistryRules(result, registry, nil)
+
+	if strings.Join(got.Docs, ",") != "docs/api-v2.md" || len(dropped) != 1 {
+		t.Errorf("expected only the higher-scored v2 guide, got %v (dropped %v)", got.Docs, dropped)
+	}
+	if len(got.Skills) != 1 || len(got.Items) != 2 {
+		t.Errorf("skills should be untouched, got %v / %+v", got.Skills, got.Items)
+	}
+}
+
+func TestRoute_SectionsFollowFileRules(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	root := t.TempDir()
+	writeDoc(t, root, "docs/deploy.md", "---\nsummary: Deploys\nread_when: [deploy]\nskip_when: [read-only]\nsections: true\n---\n## Rollback\n\nUndo a deploy.\n")
+	writeDoc(t, root, "docs/api-v1.md", "---\nsummary: Old API\nread_when: [api]\nconflicts_with: [docs/api-v2.md]\nsections: true\n---\n## Auth\n\nv1 tokens.\n")
+	writeDoc(t, root, "docs/api-v2.md", "---\nsummary: New API\nread_when: [api]\n---\nv2.\n")
+	registry, err := Discover(root, DiscoverConfig{SkipStale: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	route := func(text, response string) *RouteResult {
+		t.Helper()
+		r := &Router{Config: DefaultConfig(), Root: root, Provider: StaticProvider{Response: response}}
+		result, _, err := r.Route(RouteInput{Messages: []Message{{Type: "user", Text: text}}, Registry: registry})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	got := route("how do I roll back? this is a read-only question", `{"items":[{"kind":"doc","name":"docs/deploy.md#rollback","score":0.9}]}`)
+	if len(got.Docs) != 0 {
+		t.Errorf("skip_when of the file should drop its section, got %v", got.Docs)
+	}
+	got = route("api auth", `{"items":[{"kind":"doc","name":"docs/api-v2.md","score":0.9},{"kind":"doc","name":"docs/api-v1.md#auth","score":0.8}]}`)
+	if strings.Join(got.Docs, ",") != "docs/api-v2.md" {
+		t.Errorf("a section of a conflicting doc should be dropped, got %v", got.Docs)
+	}
+}
+
+func TestApplyRegistryRules_ConflictsCoverSections(t *testing.T) {
+	registry := Registry{Docs: []RegistryDoc{
+		{Path: "docs/a.md", ConflictsWith: []string{"docs/b.md"}},
+		{Path: "docs/b.md#setup"},
+	}}
+	result := rankResult(RouteResult{Items: []RankedItem{
+		{Kind: "doc", Name: "docs/b.md#setup", Score: 0.9},
+		{Kind: "doc", Name: "docs/a.md", Score: 0.6},
+	}}, RankingConfig{})
+
+	got, _ := applyRegistryRules(result, registry, nil)
+
+	if strings.Join(got.Docs, ",") != "docs/b.md#setup" {
+		t.Errorf("a conflict with a file should cover its sections, got %v", got.Docs)
+	}
+}
@@ -0,0 +1,154 @@
+package internal
+
+import (
+	"path"
+	"strings"
+)
+
+// File-scope modes (files.mode in config).
+const (
+	FilesInclude = "include" // matched docs are added without asking the router (default)
+	FilesBoost   = "boost"   // the router sees the files; matched docs it picks are scored up
+	FilesOff     = "off"
+)
+
+// filesBoost is added to the score of a picked doc whose applies_to matches.
+const filesBoost = 0.3
+
+// metadataFiles returns the touched files from metadata as slash-separated
+// paths relative to root. Non-string entries and paths outside root are dropped.
+func metadataFiles(metadata map[string]any, root string) []string {
+	var raw []any
+	switch v := metadata[MetaFiles].(type) {
+	case []any:
+		raw = v
+	case []string:
+		for _, p := range v {
+			raw = append(raw, p)
+		}
+	}
+	var files []string
+	seen := map[string]bool{}
+	for _, v := range raw {
+		p, _ := v.(string)
+		if p = relPath(p, root); p != "" && p != "." && !seen[p] {
+			seen[p] = true
+			files = append(files, p)
+		}
+	}
+	return files
+}
+
+// matchGlob reports whether file matches a gitignore-style pattern: "**"
+// spans any number of directories, and a pattern without a slash matches the
+// file's base name at any depth.
+func matchGlob(pattern, file string) bool {
+	pattern = strings.TrimPrefix(pattern, "./")
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(file))
+		return ok
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(file, "/"))
+}
+
+func matchSegments(pat, parts []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(pat[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pat[0], parts[0]); !ok {
+			return false
+		}
+		pat, parts = pat[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
+
+// appliesTo returns the first touched file matched by one of d's globs.
+func appliesTo(d RegistryDoc, files []string) (string, bool) {
+	for _, f := range files {
+		for _, g := range d.AppliesTo {
+			if matchGlob(g, f) {
+				return f, true
+			}
+		}
+	}
+	return "", false
+}
+
+// splitScoped separates docs whose applies_to matches a touched file, for
+// inclusion without the router. Sections follow the globs of their file; once
+// a whole file is included, its sections leave the registry. Docs the session
+// has already been given stay in rest and are filtered out as usual.
+func splitScoped(registry Registry, session SessionState, files []string) (scoped []RankedItem, rest Registry) {
+	rest = Registry{Docs: []RegistryDoc{}, Skills: registry.Skills, Items: registry.Items}
+	given := session.docsGiven()
+	read := make(map[string]bool, len(given))
+	for _, d := range given {
+		read[d] = true
+	}
+	docs := docsByPath(registry)
+	matched := map[string]string{}
+	for _, d := range registry.Docs {
+		file, _ := splitDocRef(d.Path)
+		if f, ok := appliesTo(withFileGlobs(d, docs), files); ok && !read[d.Path] && !read[file] {
+			matched[d.Path] = f
+		}
+	}
+	for _, d := range registry.Docs {
+		if file, anchor := splitDocRef(d.Path); anchor != "" && matched[file] != "" {
+			continue // the whole file is included
+		}
+		if f, ok := matched[d.Path]; ok {
+			scoped = append(scoped, RankedItem{Kind: "doc", Name: d.Path, Score: 1, Reason: "applies to " + f, Source: "scope"})
+			continue
+		}
+		rest.Docs = append(rest.Docs, d)
+	}
+	return scoped, rest
+}
+
+// boostScoped raises the scores of picked docs whose applies_to matches a
+// touched file, before ranking thresholds and caps are applied.
+func boostScoped(r RouteResult, registry Registry, files []string) RouteResult {
+	if len(files) == 0 {
+		return r
+	}
+	docs := docsByPath(registry)
+	items := make([]RankedItem, len(r.Items))
+	for i, it := range r.Items {
+		if f, ok := appliesTo(withFileGlobs(docs[it.Name], docs), files); ok && it.Kind == "doc" {
+			it.Score = min(it.Score+filesBoost, 1)
+			if it.Reason == "" {
+				it.Reason = "applies to " + f
+			}
+		}
+		items[i] = it
+	}
+	r.Items = items
+	return r
+}
+
+// withFileGlobs adds the applies_to globs of a section's file to d.
+func withFileGlobs(d RegistryDoc, docs map[string]RegistryDoc) RegistryDoc {
+	if file, anchor := splitDocRef(d.Path); anchor != "" {
+		d.AppliesTo = union(d.AppliesTo, docs[file].AppliesTo)
+	}
+	return d
+}
+
+func docsByPath(registry Registry) map[string]RegistryDoc {
+	docs := make(map[string]RegistryDoc, len(registry.Docs))
+	for _, d := range registry.Docs {
+		docs[d.Path] = d
+	}
+	return docs
+}
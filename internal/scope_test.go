@@ -0,0 +1,144 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatchGlob(t *testing.T) {
+	cases := []struct {
+		pattern, file string
+		want          bool
+	}{
+		{"src/billing/**", "src/billing/invoice.go", true},
+		{"src/billing/**", "src/billing/tax/vat.go", true},
+		{"src/billing/**", "src/billingx/a.go", false},
+		{"src/**/*.sql", "src/db/migrations/001.sql", true},
+		{"src/**/*.sql", "src/schema.sql", true},
+		{"src/*.go", "src/api/handler.go", false},
+		{"*.proto", "api/v1/user.proto", true},
+		{"./docs/*.md", "docs/a.md", true},
+		{"Dockerfile", "deploy/Dockerfile", true},
+	}
+	for _, c := range cases {
+		if got := matchGlob(c.pattern, c.file); got != c.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", c.pattern, c.file, got, c.want)
+		}
+	}
+}
+
+func TestMetadataFiles(t *testing.T) {
+	meta := map[string]any{MetaFiles: []any{"/repo/src/a.go", "src/../src/a.go", "/elsewhere/b.go", 3, "lib/c.go"}}
+	if got := strings.Join(metadataFiles(meta, "/repo"), ","); got != "src/a.go,lib/c.go" {
+		t.Errorf("got %s", got)
+	}
+	if got := metadataFiles(map[string]any{"files": "src/a.go"}, "/repo"); got != nil {
+		t.Errorf("non-list files should be ignored, got %v", got)
+	}
+}
+
+func scopedRegistry() Registry {
+	return Registry{Docs: []RegistryDoc{
+		{Path: "docs/billing.md", Summary: "billing", AppliesTo: []string{"src/billing/**"}},
+		{Path: "docs/auth.md", Summary: "auth"},
+	}}
+}
+
+func TestRoute_AppliesToIncludesDoc(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	r := &Router{Config: DefaultConfig(), Root: "/repo", Provider: StaticProvider{Response: `{"items":[{"kind":"doc","name":"docs/auth.md","score":0.8}]}`}}
+
+	result, info, err := r.Route(RouteInput{
+		Messages: []Message{{Type: "user", Text: "fix the rounding bug"}},
+		Registry: scopedRegistry(),
+		Metadata: map[string]any{MetaFiles: []any{"/repo/src/billing/invoice.go"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(result.Docs, ","); got != "docs/billing.md,docs/auth.md" {
+		t.Errorf("expected scoped doc first, got %s", got)
+	}
+	if result.Items[0].Reason != "applies to src/billing/invoice.go" {
+		t.Errorf("unexpected reason %q", result.Items[0].Reason)
+	}
+	if strings.Contains(info.Prompt, "docs/billing.md") {
+		t.Error("scoped doc should not be offered to the router")
+	}
+
+	// Already read this session: nothing is added
+	result, _, _ = r.Route(RouteInput{
+		Messages: []Message{{Type: "user", Text: "fix the rounding bug"}},
+		Registry: scopedRegistry(),
+		Session:  SessionState{DocsRead: []string{"docs/billing.md"}},
+		Metadata: map[string]any{MetaFiles: []any{"src/billing/invoice.go"}},
+	})
+	if got := strings.Join(result.Docs, ","); got != "docs/auth.md" {
+		t.Errorf("read scoped doc should not repeat, got %s", got)
+	}
+}
+
+func TestRoute_AppliesToBoost(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg := DefaultConfig()
+	cfg.Files.Mode = FilesBoost
+	cfg.Ranking.MinScore = 0.5
+	r := &Router{Config: cfg, Provider: StaticProvider{Response: `{"items":[
+		{"kind":"doc","name":"docs/billing.md","score":0.4},
+		{"kind":"doc","name":"docs/auth.md","score":0.45}]}`}}
+
+	result, info, err := r.Route(RouteInput{
+		Messages: []Message{{Type: "user", Text: "fix the rounding bug"}},
+		Registry: scopedRegistry(),
+		Metadata: map[string]any{MetaFiles: []any{"src/billing/invoice.go"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(result.Docs, ","); got != "docs/billing.md" {
+		t.Errorf("expected boosted doc above min_score, got %s", got)
+	}
+	if !strings.Contains(info.Prompt, "docs/billing.md") {
+		t.Error("in boost mode the router should still see scoped docs")
+	}
+}
+
+func TestRoute_AppliesToCoversSections(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	registry := scopedRegistry()
+	registry.Docs = append(registry.Docs, RegistryDoc{Path: "docs/billing.md#refunds", Summary: "refunds"})
+	input := RouteInput{
+		Messages: []Message{{Type: "user", Text: "fix the rounding bug"}},
+		Registry: registry,
+		Metadata: map[string]any{MetaFiles: []any{"src/billing/invoice.go"}},
+	}
+
+	r := &Router{Config: DefaultConfig(), Provider: StaticProvider{Response: `{"items":[]}`}}
+	result, info, err := r.Route(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(result.Docs, ","); got != "docs/billing.md" || strings.Contains(info.Prompt, "docs/billing.md#refunds") {
+		t.Errorf("the included file should cover its sections, got %s", got)
+	}
+
+	// Discovered sections carry their file's globs, and match on their own
+	registry.Docs = registry.Docs[1:]
+	registry.Docs[1].AppliesTo = []string{"src/billing/**"}
+	input.Registry = registry
+	result, _, _ = r.Route(input)
+	if got := strings.Join(result.Docs, ","); got != "docs/billing.md#refunds" {
+		t.Errorf("expected the scoped section, got %s", got)
+	}
+
+	cfg := DefaultConfig()
+	cfg.Files.Mode = FilesBoost
+	cfg.Ranking.MinScore = 0.5
+	input.Registry = scopedRegistry()
+	input.Registry.Docs = append(input.Registry.Docs, RegistryDoc{Path: "docs/billing.md#refunds", Summary: "refunds"})
+	r = &Router{Config: cfg, Provider: StaticProvider{Response: `{"items":[{"kind":"doc","name":"docs/billing.md#refunds","score":0.4}]}`}}
+	result, _, _ = r.Route(input)
+	if got := strings.Join(result.Docs, ","); got != "docs/billing.md#refunds" {
+		t.Errorf("a section should be boosted by its file's globs, got %s", got)
+	}
+}
@@ -0,0 +1,110 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// trivialSkipPrefix starts every skip reason produced by the trivial-message classifier.
+const trivialSkipPrefix = "trivial message"
+
+// defaultStopPhrases are acknowledgements that never need new context.
+// User-configured stop phrases are added to this list, not substituted for it.
+var defaultStopPhrases = []string{
+	"ok", "okay", "k", "kk", "yes", "yep", "yeah", "y", "no", "nope", "n", "sure",
+	"thanks", "thank you", "thx", "ty", "cheers", "cool", "nice", "great", "perfect",
+	"awesome", "good", "got it", "sounds good", "lgtm", "done", "agreed",
+	"continue", "go on", "go ahead", "proceed", "keep going", "next",
+	// Common acknowledgements in other languages
+	"merci", "gracias", "danke", "grazie", "obrigado", "да", "ок", "спасибо", "はい", "好的", "谢谢",
+}
+
+// IsTrivialSkip reports whether a skip reason came from the trivial-message classifier.
+func IsTrivialSkip(reason string) bool {
+	return strings.HasPrefix(reason, trivialSkipPrefix)
+}
+
+// classifyTrivial decides whether the latest user message is too trivial to route.
+// Patterns only apply once compiled (LoadConfig does this).
+// Returns a skip reason, or "" if the message should go to the model.
+func classifyTrivial(messages []Message, cfg SkipConfig) string {
+	if cfg.Disabled {
+		return ""
+	}
+	text, ok := lastUserText(messages)
+	if !ok {
+		return ""
+	}
+
+	for _, re := range cfg.patterns {
+		if re.MatchString(text) {
+			return fmt.Sprintf("%s: matches pattern %q", trivialSkipPrefix, re.String())
+		}
+	}
+
+	if !hasLetterOrDigit(text) {
+		return trivialSkipPrefix + ": no text content"
+	}
+
+	norm := normalizeMessage(text)
+	if utf8.RuneCountInString(norm) < cfg.MinLength {
+		return fmt.Sprintf("%s: shorter than %d characters", trivialSkipPrefix, cfg.MinLength)
+	}
+
+	phrases := make(map[string]bool, len(defaultStopPhrases)+len(cfg.StopPhrases))
+	for _, p := range defaultStopPhrases {
+		phrases[normalizeMessage(p)] = true
+	}
+	for _, p := range cfg.StopPhrases {
+		phrases[normalizeMessage(p)] = true
+	}
+
+	if phrases[norm] {
+		return fmt.Sprintf("%s: stop phrase %q", trivialSkipPrefix, norm)
+	}
+	// "ok thanks", "yes, continue" — every word is an acknowledgement on its own
+	words := strings.Fields(norm)
+	for _, w := range words {
+		if !phrases[w] {
+			return ""
+		}
+	}
+	return fmt.Sprintf("%s: stop phrase %q", trivialSkipPrefix, norm)
+}
+
+// lastUserText returns the text of the most recent user message.
+func lastUserText(messages []Message) (string, bool) {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Type == "user" {
+			return strings.TrimSpace(messages[i].Text), true
+		}
+	}
+	return "", false
+}
+
+// hasLetterOrDigit reports whether s contains any letter or digit in any script.
+// Messages made only of emoji, punctuation and whitespace are acknowledgements.
+func hasLetterOrDigit(s string) bool {
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeMessage lowercases s, drops punctuation and symbols, and collapses whitespace.
+func normalizeMessage(s string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r):
+			sb.WriteRune(r)
+		case unicode.IsSpace(r), unicode.IsPunct(r), unicode.IsSymbol(r):
+			sb.WriteByte(' ')
+		}
+	}
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
@@ -0,0 +1,96 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func userMsg(text string) []Message {
+	return []Message{
+		{Type: "user", Text: "help me set up OAuth"},
+		{Type: "assistant", Text: "Here is the plan."},
+		{Type: "user", Text: text},
+	}
+}
+
+func TestClassifyTrivial_StopPhrases(t *testing.T) {
+	cfg := DefaultConfig().Skip
+	for _, text := range []string{"thanks", "Thanks!", "ok", "OK.", "continue", "ok, thanks", "yes continue", "спасибо"} {
+		if reason := classifyTrivial(userMsg(text), cfg); !IsTrivialSkip(reason) {
+			t.Errorf("%q should be trivial, got reason %q", text, reason)
+		}
+	}
+}
+
+func TestClassifyTrivial_EmojiOnly(t *testing.T) {
+	cfg := DefaultConfig().Skip
+	for _, text := range []string{"👍", "🙏🙏", "...", "?!"} {
+		reason := classifyTrivial(userMsg(text), cfg)
+		if reason != trivialSkipPrefix+": no text content" {
+			t.Errorf("%q should be skipped for no text content, got %q", text, reason)
+		}
+	}
+}
+
+func TestClassifyTrivial_RealRequestsAreRouted(t *testing.T) {
+	cfg := DefaultConfig().Skip
+	for _, text := range []string{"fix it", "no, use postgres instead", "continue with the auth refactor", "deploy to prod"} {
+		if reason := classifyTrivial(userMsg(text), cfg); reason != "" {
+			t.Errorf("%q should be routed, got skip reason %q", text, reason)
+		}
+	}
+}
+
+func TestClassifyTrivial_MinLength(t *testing.T) {
+	cfg := SkipConfig{MinLength: 5}
+	reason := classifyTrivial(userMsg("abc"), cfg)
+	if !strings.Contains(reason, "shorter than 5") {
+		t.Errorf("expected min length skip, got %q", reason)
+	}
+}
+
+func TestClassifyTrivial_ConfiguredStopPhrasesAndPatterns(t *testing.T) {
+	cfg := SkipConfig{
+		StopPhrases: []string{"ship it"},
+		Patterns:    []string{`^/\w+$`, `(`},
+	}
+	cfg.compilePatterns()
+	if reason := classifyTrivial(userMsg("Ship it!"), cfg); !IsTrivialSkip(reason) {
+		t.Errorf("configured stop phrase should be trivial, got %q", reason)
+	}
+	if reason := classifyTrivial(userMsg("/clear"), cfg); !strings.Contains(reason, "matches pattern") {
+		t.Errorf("configured pattern should be trivial, got %q", reason)
+	}
+}
+
+func TestClassifyTrivial_DisabledAndNoUserMessage(t *testing.T) {
+	if reason := classifyTrivial(userMsg("thanks"), SkipConfig{Disabled: true}); reason != "" {
+		t.Errorf("disabled classifier should not skip, got %q", reason)
+	}
+	msgs := []Message{{Type: "assistant", Text: "ok"}}
+	if reason := classifyTrivial(msgs, DefaultConfig().Skip); reason != "" {
+		t.Errorf("no user message should not skip, got %q", reason)
+	}
+}
+
+func TestRoute_TrivialMessageSkipsLLM(t *testing.T) {
+	input := RouteInput{
+		Messages: userMsg("thanks!"),
+		Registry: Registry{
+			Docs:   []RegistryDoc{{Path: "docs/a.md", Summary: "a"}},
+			Skills: []RegistrySkill{},
+		},
+	}
+
+	result, info, err := Route(input, DefaultConfig())
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !IsTrivialSkip(info.SkipReason) {
+		t.Errorf("expected trivial skip reason, got %q", info.SkipReason)
+	}
+	if len(result.Docs) != 0 || len(result.Skills) != 0 {
+		t.Errorf("expected empty result, got docs=%v skills=%v", result.Docs, result.Skills)
+	}
+}
@@ -0,0 +1,269 @@
+package internal
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lastUpdatedLayouts are the accepted `last_updated` formats, besides YAML timestamps.
+var lastUpdatedLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02 15:04 MST",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// parseLastUpdated parses a `last_updated` value. A bare date counts as the
+// end of that day, so same-day commits don't make a doc stale.
+func parseLastUpdated(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	for _, layout := range lastUpdatedLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			if layout == "2006-01-02" {
+				t = t.Add(24*time.Hour - time.Second)
+			}
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// fmLastUpdated returns the frontmatter `last_updated` value as a string.
+// YAML parses unquoted dates as timestamps; midnight ones are given back as
+// bare dates.
+func fmLastUpdated(fm map[string]any) string {
+	if t, ok := fm["last_updated"].(time.Time); ok {
+		if t.Equal(t.Truncate(24 * time.Hour)) {
+			return t.Format("2006-01-02")
+		}
+		return t.Format(time.RFC3339)
+	}
+	return fmString(fm, "last_updated")
+}
+
+var (
+	// mdLinkRe matches the target of a markdown link or image: [text](target)
+	mdLinkRe = regexp.MustCompile(`\]\(([^)\s]+)`)
+	// codePathRe matches inline code that looks like a file path: `src/auth/login.go`
+	codePathRe = regexp.MustCompile("`([A-Za-z0-9_.-]+(?:/[A-Za-z0-9_.-]+)+)`")
+)
+
+// docReferences returns the project files a doc links to or names in inline
+// code, as slash-separated paths relative to root. Links resolve against the
+// doc's directory; code spans against the root, then the doc's directory.
+// Only files that exist are returned.
+func docReferences(root, rel, text string) []string {
+	dir := path.Dir(rel)
+	seen := map[string]bool{rel: true}
+	var refs []string
+	add := func(candidates ...string) {
+		for _, p := range candidates {
+			p = path.Clean(p)
+			if p == "." || strings.HasPrefix(p, "../") || p == ".." {
+				continue
+			}
+			if info, err := os.Stat(filepath.Join(root, filepath.FromSlash(p))); err != nil || info.IsDir() {
+				continue
+			}
+			if !seen[p] {
+				seen[p] = true
+				refs = append(refs, p)
+			}
+			return
+		}
+	}
+	for _, m := range mdLinkRe.FindAllStringSubmatch(text, -1) {
+		target, _, _ := strings.Cut(m[1], "#")
+		if target == "" || strings.Contains(target, "://") || strings.HasPrefix(target, "mailto:") {
+			continue
+		}
+		if strings.HasPrefix(target, "/") {
+			add(strings.TrimPrefix(target, "/"))
+		} else {
+			add(path.Join(dir, target))
+		}
+	}
+	for _, m := range codePathRe.FindAllStringSubmatch(text, -1) {
+		add(m[1], path.Join(dir, m[1]))
+	}
+	return refs
+}
+
+// markStale flags docs whose referenced files were committed after the doc
+// was last updated: its `last_updated` date, or else its own last commit.
+// Sections share the flag of their file. refs maps doc file paths to their
+// references. Outside a git repository nothing is flagged.
+func markStale(root string, docs []RegistryDoc, refs map[string][]string) {
+	var paths []string
+	for file, r := range refs {
+		if len(r) > 0 {
+			paths = append(paths, file)
+			paths = append(paths, r...)
+		}
+	}
+	if len(paths) == 0 {
+		return
+	}
+	commits := lastCommitTimes(root, paths)
+	if commits == nil {
+		return
+	}
+
+	verdicts := map[string]string{}
+	for i := range docs {
+		if file, anchor := splitDocRef(docs[i].Path); anchor == "" {
+			verdicts[file] = staleReason(docs[i].LastUpdated, commits[file], refs[file], commits)
+		}
+	}
+	for i := range docs {
+		file, _ := splitDocRef(docs[i].Path)
+		docs[i].StaleReason = verdicts[file]
+		docs[i].Stale = docs[i].StaleReason != ""
+	}
+}
+
+// staleReason explains why a doc is stale, or returns "".
+func staleReason(lastUpdated string, docCommit time.Time, refs []string, commits map[string]time.Time) string {
+	updated, since := docCommit, "its last commit"
+	if t, ok := parseLastUpdated(lastUpdated); ok {
+		updated, since = t, "last_updated"
+	}
+	if updated.IsZero() {
+		return ""
+	}
+	var newest string
+	for _, r := range refs {
+		if t := commits[r]; t.After(updated) && (newest == "" || t.After(commits[newest])) {
+			newest = r
+		}
+	}
+	if newest == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s changed %s, after %s (%s)", newest, commits[newest].Format("2006-01-02"), since, updated.Format("2006-01-02"))
+}
+
+// staleHistory is how many recent commits the stale check walks. Files not
+// changed in them count as old, so docs referencing them aren't flagged.
+const staleHistory = 1000
+
+// staleCache is what lastCommitTimes keeps per repository, so routes after
+// the first on the same HEAD don't walk history again.
+type staleCache struct {
+	Key   string               `json:"key"` // HEAD and the paths asked about
+	Times map[string]time.Time `json:"times"`
+}
+
+// StaleCacheDir returns ~/.config/reflex/stale, where commit times for the
+// stale check are cached, one file per project root.
+func StaleCacheDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "reflex", "stale")
+}
+
+// lastCommitTimes returns the time of the latest commit touching each path,
+// relative to root, within the last staleHistory commits. Results are cached
+// in StaleCacheDir until HEAD moves. Returns nil if git fails.
+func lastCommitTimes(root string, paths []string) map[string]time.Time {
+	out, err := exec.Command("git", "-C", root, "rev-parse", "HEAD").Output()
+	head := strings.TrimSpace(string(out))
+	if err != nil || head == "" {
+		return nil
+	}
+	want := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		want[p] = true
+	}
+	sorted := make([]string, 0, len(want))
+	for p := range want {
+		sorted = append(sorted, p)
+	}
+	sort.Strings(sorted)
+	key := hashString(head + "\x00" + strings.Join(sorted, "\x00"))
+
+	var cachePath string
+	if dir := StaleCacheDir(); dir != "" {
+		abs, _ := filepath.Abs(root)
+		cachePath = filepath.Join(dir, hashString(abs)[:16]+".json")
+	}
+	var cached staleCache
+	if data, err := os.ReadFile(cachePath); err == nil && json.Unmarshal(data, &cached) == nil && cached.Key == key {
+		return cached.Times
+	}
+	times, ok := walkCommitTimes(root, want)
+	if !ok {
+		return nil
+	}
+	if data, err := json.Marshal(staleCache{Key: key, Times: times}); err == nil && cachePath != "" {
+		os.MkdirAll(filepath.Dir(cachePath), 0755)
+		os.WriteFile(cachePath, data, 0644)
+	}
+	return times
+}
+
+func hashString(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// walkCommitTimes reads recent history newest first, stopping once every
+// wanted path has been seen. Paths are matched here rather than passed to git,
+// so a large registry can't overflow the command line.
+func walkCommitTimes(root string, want map[string]bool) (map[string]time.Time, bool) {
+	cmd := exec.Command("git", "-C", root, "log", "--relative", "--format=%x00%ct", "--name-only", "--max-count="+strconv.Itoa(staleHistory))
+	stdout, err := cmd.StdoutPipe()
+	if err != nil || cmd.Start() != nil {
+		return nil, false
+	}
+	times := map[string]time.Time{}
+	var current time.Time
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() && len(times) < len(want) {
+		s := scanner.Text()
+		if ts, ok := strings.CutPrefix(s, "\x00"); ok {
+			sec, _ := strconv.ParseInt(ts, 10, 64)
+			current = time.Unix(sec, 0).UTC()
+			continue
+		}
+		if _, seen := times[s]; want[s] && !seen { // newest commit comes first
+			times[s] = current
+		}
+	}
+	if len(times) == len(want) {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return times, true
+	}
+	return times, cmd.Wait() == nil
+}
+
+// annotateStale marks result items whose registry doc is stale. The flag
+// comes from the registry only, never from the model.
+func annotateStale(result *RouteResult, registry Registry) {
+	stale := map[string]bool{}
+	for _, d := range registry.Docs {
+		if d.Stale {
+			stale[d.Path] = true
+		}
+	}
+	for i, it := range result.Items {
+		result.Items[i].Stale = it.Kind == "doc" && stale[it.Name]
+	}
+}
@@ -0,0 +1,128 @@
+package internal
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// gitCommit commits everything in root with the given commit date.
+func gitCommit(t *testing.T, root, date string) {
+	t.Helper()
+	for _, args := range [][]string{{"init", "-q"}, {"add", "-A"}, {"commit", "-q", "-m", "update"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = root
+		cmd.Env = append(cmd.Environ(),
+			"GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@example.com", "GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@example.com",
+			"GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date, "GIT_CONFIG_GLOBAL=/dev/null")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+}
+
+func TestDiscover_StaleDocs(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("HOME", t.TempDir())
+	root := t.TempDir()
+	writeDoc(t, root, "src/auth/login.go", "package auth\n")
+	writeDoc(t, root, "docs/auth.md", "---\nsummary: Auth\nread_when: [login]\nlast_updated: 2024-03-01\n---\nSee `src/auth/login.go`.\n")
+	writeDoc(t, root, "docs/fresh.md", "---\nsummary: Fresh\nread_when: [x]\nlast_updated: \"2024-06-01 10:00 UTC\"\n---\nSee [login](../src/auth/login.go).\n")
+	writeDoc(t, root, "docs/undated.md", "---\nsummary: Undated\nread_when: [y]\n---\nSee `src/auth/login.go` and `src/missing.go`.\n")
+	gitCommit(t, root, "2024-03-01T12:00:00Z")
+	writeDoc(t, root, "src/auth/login.go", "package auth\n\nfunc Login() {}\n")
+	gitCommit(t, root, "2024-04-15T12:00:00Z")
+
+	reg, err := Discover(root, DiscoverConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale := map[string]string{}
+	for _, d := range reg.Docs {
+		if d.Stale {
+			stale[d.Path] = d.StaleReason
+		}
+	}
+	if got := stale["docs/auth.md"]; !strings.Contains(got, "src/auth/login.go changed 2024-04-15, after last_updated (2024-03-01)") {
+		t.Errorf("auth.md: got %q", got)
+	}
+	if got := stale["docs/undated.md"]; !strings.Contains(got, "after its last commit") {
+		t.Errorf("undated.md should fall back to its own commit time, got %q", got)
+	}
+	if _, ok := stale["docs/fresh.md"]; ok {
+		t.Error("fresh.md was updated after the code changed")
+	}
+
+	reg, _ = Discover(root, DiscoverConfig{SkipStale: true})
+	for _, d := range reg.Docs {
+		if d.Stale {
+			t.Errorf("skip_stale: %s flagged", d.Path)
+		}
+	}
+}
+
+func TestLastCommitTimes_CachedUntilHeadMoves(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("HOME", t.TempDir())
+	root := t.TempDir()
+	writeDoc(t, root, "src/a.go", "package a\n")
+	writeDoc(t, root, "src/b.go", "package b\n")
+	gitCommit(t, root, "2024-03-01T12:00:00Z")
+
+	times := lastCommitTimes(root, []string{"src/a.go", "src/missing.go"})
+	if got := times["src/a.go"].Format("2006-01-02"); got != "2024-03-01" || len(times) != 1 {
+		t.Fatalf("unexpected times: %v", times)
+	}
+	if files, _ := filepath.Glob(filepath.Join(StaleCacheDir(), "*.json")); len(files) != 1 {
+		t.Errorf("expected the result cached in the stale cache dir, got %v", files)
+	}
+	if _, err := os.Stat(filepath.Join(root, ".git", "reflex-stale.json")); err == nil {
+		t.Error("nothing should be written inside .git")
+	}
+
+	writeDoc(t, root, "src/a.go", "package a\n\nfunc A() {}\n")
+	gitCommit(t, root, "2024-05-01T12:00:00Z")
+	if got := lastCommitTimes(root, []string{"src/a.go", "src/missing.go"})["src/a.go"].Format("2006-01-02"); got != "2024-05-01" {
+		t.Errorf("a new commit should invalidate the cache, got %s", got)
+	}
+}
+
+func TestParseLastUpdated(t *testing.T) {
+	for _, s := range []string{"2026-03-06", "2026-03-06 19:55 PST", "2026-03-06T19:55:00Z", "2026-03-06 19:55"} {
+		if _, ok := parseLastUpdated(s); !ok {
+			t.Errorf("failed to parse %q", s)
+		}
+	}
+	if _, ok := parseLastUpdated("last week"); ok {
+		t.Error("expected failure")
+	}
+	if got := fmLastUpdated(parseFrontmatter("---\nlast_updated: 2024-01-15\n---\n")); got != "2024-01-15" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestRoute_StaleAnnotation(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	r := &Router{Config: DefaultConfig(), Provider: StaticProvider{Response: `{"items":[
+		{"kind":"doc","name":"docs/auth.md","score":0.9},
+		{"kind":"doc","name":"docs/api.md","score":0.8,"stale":true}]}`}}
+	result, _, err := r.Route(RouteInput{
+		Messages: []Message{{Type: "user", Text: "how does login work?"}},
+		Registry: Registry{Docs: []RegistryDoc{
+			{Path: "docs/auth.md", Summary: "auth", Stale: true},
+			{Path: "docs/api.md", Summary: "api"},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Items[0].Stale || result.Items[1].Stale {
+		t.Errorf("stale flags should come from the registry, got %+v", result.Items)
+	}
+}
@@ -0,0 +1,289 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Store persists routing logs, per-session injection state, and cached decisions.
+// The "jsonl" backend uses the original files under ~/.config/reflex/; "sqlite" keeps
+// everything in one indexed database.
+type Store interface {
+	AppendLog(entry LogEntry) error
+	// ReadLog returns matching entries, oldest first.
+	ReadLog(q LogQuery) ([]LogEntry, error)
+
+	AppendFeedback(f Feedback) error
+	// ReadFeedback returns all feedback, oldest first.
+	ReadFeedback() ([]Feedback, error)
+
+	LoadSession(key string) (SessionState, error)
+	SaveSession(key string, state SessionState) error
+	ListSessions() ([]SessionInfo, error)
+	DeleteSession(key string) error
+
+	CacheGet(key string) *RouteResult
+	CachePut(key, model string, result *RouteResult)
+	CacheStats() (CacheStats, error)
+	CacheClear() (int, error)
+
+	Close() error
+}
+
+// LogQuery filters log reads. Zero fields match everything.
+type LogQuery struct {
+	Since  time.Time
+	Status string
+	ID     string
+	Limit  int // newest N matching entries
+}
+
+// SessionInfo describes one stored session.
+type SessionInfo struct {
+	Key     string
+	Updated time.Time
+	State   SessionState
+}
+
+// StateDir returns ~/.config/reflex/state, where the jsonl backend keeps session files.
+func StateDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "reflex", "state")
+}
+
+// OpenStore opens the storage backend selected in config.
+func OpenStore(cfg *Config) (Store, error) {
+	switch cfg.Storage.Backend {
+	case "", "jsonl":
+		return &fileStore{cfg: cfg}, nil
+	case "sqlite":
+		s, err := openSQLiteStore(cfg, cfg.Storage.dbPath())
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q (use jsonl or sqlite)", cfg.Storage.Backend)
+	}
+}
+
+// MergeSessions returns the union of two session states.
+func MergeSessions(a, b SessionState) SessionState {
+	return SessionState{
+		DocsRead:   union(a.DocsRead, b.DocsRead),
+		SkillsUsed: union(a.SkillsUsed, b.SkillsUsed),
+		ItemsUsed:  union(a.ItemsUsed, b.ItemsUsed),
+		Pending:    union(a.Pending, b.Pending),
+		Ignored:    union(a.Ignored, b.Ignored),
+		Reminded:   union(a.Reminded, b.Reminded),
+	}
+}
+
+// RecordInjection adds the items in result to session. Docs whose content was
+// inlined count as read; the others stay pending until CheckCompliance finds a
+// Read call for them. Reminders are recorded as sent.
+func RecordInjection(session SessionState, result *RouteResult) SessionState {
+	inlined := make(map[string]bool, len(result.Content))
+	for _, c := range result.Content {
+		inlined[c.Path] = true
+	}
+	var read, pending []string
+	for _, d := range result.Docs {
+		if inlined[d] {
+			read = append(read, d)
+		} else {
+			pending = append(pending, d)
+		}
+	}
+	var items, reminded []string
+	for _, it := range result.Items {
+		if it.Kind != "doc" && it.Kind != "skill" {
+			items = append(items, itemKey(it.Kind, it.Name))
+		}
+		if it.Reminder {
+			reminded = append(reminded, it.Name)
+		}
+	}
+	return MergeSessions(session, SessionState{DocsRead: read, SkillsUsed: result.Skills, ItemsUsed: items, Pending: pending, Reminded: reminded})
+}
+
+// docsGiven returns the docs the session has already been given: those read,
+// those still pending a read, and those it ignored, which only come back as
+// reminders.
+func (s SessionState) docsGiven() []string {
+	return union(union(s.DocsRead, s.Pending), s.Ignored)
+}
+
+// union returns the items of a followed by new items of b, without duplicates.
+func union(a, b []string) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	out := []string{}
+	for _, list := range [][]string{a, b} {
+		for _, s := range list {
+			if !seen[s] {
+				seen[s] = true
+				out = append(out, s)
+			}
+		}
+	}
+	return out
+}
+
+// matchLog reports whether e passes the query's filters.
+func (q LogQuery) matchLog(e LogEntry) bool {
+	if q.Status != "" && e.Status != q.Status {
+		return false
+	}
+	if q.ID != "" && e.ID != q.ID {
+		return false
+	}
+	if !q.Since.IsZero() {
+		ts, err := time.Parse(time.RFC3339, e.Timestamp)
+		if err != nil || ts.Before(q.Since) {
+			return false
+		}
+	}
+	return true
+}
+
+// fileStore is the jsonl backend: log.jsonl plus archives, one JSON file per
+// session in StateDir, and one JSON file per cached decision in CacheDir.
+type fileStore struct {
+	cfg *Config
+}
+
+func (s *fileStore) AppendLog(entry LogEntry) error {
+	AppendLog(s.cfg.Log, entry)
+	return nil
+}
+
+func (s *fileStore) ReadLog(q LogQuery) ([]LogEntry, error) {
+	n := q.Limit
+	if !q.Since.IsZero() || q.Status != "" || q.ID != "" {
+		n = 0 // filters need the full history
+	}
+	entries, err := ReadLog(n)
+	if err != nil {
+		return nil, err
+	}
+	out := entries[:0]
+	for _, e := range entries {
+		if q.matchLog(e) {
+			out = append(out, e)
+		}
+	}
+	if q.Limit > 0 && len(out) > q.Limit {
+		out = out[len(out)-q.Limit:]
+	}
+	return out, nil
+}
+
+// sessionPath maps a session key to its state file, rejecting keys that would escape StateDir.
+func (s *fileStore) sessionPath(key string) (string, error) {
+	if key == "" || strings.ContainsAny(key, `/\`) || key == "." || key == ".." {
+		return "", fmt.Errorf("invalid session key %q", key)
+	}
+	return filepath.Join(StateDir(), key+".json"), nil
+}
+
+func (s *fileStore) LoadSession(key string) (SessionState, error) {
+	p, err := s.sessionPath(key)
+	if err != nil {
+		return SessionState{}, err
+	}
+	data, err := os.ReadFile(p)
+	if os.IsNotExist(err) {
+		return SessionState{}, nil
+	}
+	if err != nil {
+		return SessionState{}, err
+	}
+	var state SessionState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return SessionState{}, fmt.Errorf("malformed session %s: %w", key, err)
+	}
+	return state, nil
+}
+
+func (s *fileStore) SaveSession(key string, state SessionState) error {
+	p, err := s.sessionPath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	data, _ := json.Marshal(state)
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p)
+}
+
+func (s *fileStore) ListSessions() ([]SessionInfo, error) {
+	files, err := filepath.Glob(filepath.Join(StateDir(), "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var out []SessionInfo
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			continue
+		}
+		key := strings.TrimSuffix(filepath.Base(f), ".json")
+		state, err := s.LoadSession(key)
+		if err != nil {
+			continue
+		}
+		out = append(out, SessionInfo{Key: key, Updated: info.ModTime(), State: state})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Updated.Before(out[j].Updated) })
+	return out, nil
+}
+
+func (s *fileStore) DeleteSession(key string) error {
+	p, err := s.sessionPath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *fileStore) AppendFeedback(f Feedback) error {
+	return appendFeedbackFile(FeedbackPath(), f)
+}
+
+func (s *fileStore) ReadFeedback() ([]Feedback, error) {
+	return readFeedbackFile(FeedbackPath())
+}
+
+func (s *fileStore) CacheGet(key string) *RouteResult {
+	return cacheGet(s.cfg.Cache, key)
+}
+
+func (s *fileStore) CachePut(key, model string, result *RouteResult) {
+	cachePut(s.cfg.Cache, key, model, result)
+}
+
+func (s *fileStore) CacheStats() (CacheStats, error) {
+	return ReadCacheStats(s.cfg.Cache)
+}
+
+func (s *fileStore) CacheClear() (int, error) {
+	return ClearCache()
+}
+
+func (s *fileStore) Close() error { return nil }
@@ -0,0 +1,417 @@
+package internal
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "modernc.org/sqlite" // pure-Go driver, keeps the binary cgo-free
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS logs (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	ts          TEXT NOT NULL,
+	status      TEXT NOT NULL,
+	model       TEXT NOT NULL DEFAULT '',
+	session_key TEXT NOT NULL DEFAULT '',
+	entry       TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS logs_ts ON logs(ts);
+CREATE INDEX IF NOT EXISTS logs_status_ts ON logs(status, ts);
+CREATE INDEX IF NOT EXISTS logs_session ON logs(session_key);
+
+CREATE TABLE IF NOT EXISTS feedback (
+	id     INTEGER PRIMARY KEY AUTOINCREMENT,
+	ts     TEXT NOT NULL,
+	log_id TEXT NOT NULL,
+	entry  TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS feedback_log ON feedback(log_id);
+
+CREATE TABLE IF NOT EXISTS sessions (
+	key     TEXT PRIMARY KEY,
+	updated TEXT NOT NULL,
+	state   TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS cache (
+	key     TEXT PRIMARY KEY,
+	created TEXT NOT NULL,
+	model   TEXT NOT NULL DEFAULT '',
+	result  TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS cache_created ON cache(created);
+`
+
+// sqliteStore keeps logs, sessions, and cached decisions in a single database.
+// Unlike the jsonl backend, logs are never rotated.
+type sqliteStore struct {
+	db  *sql.DB
+	cfg *Config
+}
+
+func openSQLiteStore(cfg *Config, path string) (*sqliteStore, error) {
+	if path == "" {
+		return nil, fmt.Errorf("no database path")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	// WAL + busy timeout let concurrent hook invocations write without "database is locked"
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize %s: %w", path, err)
+	}
+	return &sqliteStore{db: db, cfg: cfg}, nil
+}
+
+func (s *sqliteStore) AppendLog(entry LogEntry) error {
+	if entry.Timestamp == "" {
+		entry.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`INSERT INTO logs (ts, status, model, session_key, entry) VALUES (?, ?, ?, ?, ?)`,
+		entry.Timestamp, entry.Status, entry.Model, entry.SessionKey, string(data))
+	return err
+}
+
+func (s *sqliteStore) ReadLog(q LogQuery) ([]LogEntry, error) {
+	query := `SELECT entry FROM logs WHERE 1=1`
+	var args []any
+	if !q.Since.IsZero() {
+		query += ` AND ts >= ?`
+		args = append(args, q.Since.UTC().Format(time.RFC3339))
+	}
+	if q.Status != "" {
+		query += ` AND status = ?`
+		args = append(args, q.Status)
+	}
+	if q.ID != "" {
+		query += ` AND json_extract(entry, '$.id') = ?`
+		args = append(args, q.ID)
+	}
+	query += ` ORDER BY id DESC`
+	if q.Limit > 0 {
+		query += fmt.Sprintf(` LIMIT %d`, q.Limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []LogEntry
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			return nil, err
+		}
+		var e LogEntry
+		if err := json.Unmarshal([]byte(raw), &e); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	// Newest-first from the query; callers expect oldest first
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, rows.Err()
+}
+
+func (s *sqliteStore) AppendFeedback(f Feedback) error {
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`INSERT INTO feedback (ts, log_id, entry) VALUES (?, ?, ?)`, f.Timestamp, f.LogID, string(data))
+	return err
+}
+
+func (s *sqliteStore) ReadFeedback() ([]Feedback, error) {
+	rows, err := s.db.Query(`SELECT entry FROM feedback ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Feedback
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			return nil, err
+		}
+		var f Feedback
+		if err := json.Unmarshal([]byte(raw), &f); err == nil {
+			out = append(out, f)
+		}
+	}
+	return out, rows.Err()
+}
+
+func (s *sqliteStore) LoadSession(key string) (SessionState, error) {
+	var raw string
+	err := s.db.QueryRow(`SELECT state FROM sessions WHERE key = ?`, key).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return SessionState{}, nil
+	}
+	if err != nil {
+		return SessionState{}, err
+	}
+	var state SessionState
+	if err := json.Unmarshal([]byte(raw), &state); err != nil {
+		return SessionState{}, fmt.Errorf("malformed session %s: %w", key, err)
+	}
+	return state, nil
+}
+
+func (s *sqliteStore) SaveSession(key string, state SessionState) error {
+	return s.saveSession(key, state, time.Now())
+}
+
+func (s *sqliteStore) saveSession(key string, state SessionState, updated time.Time) error {
+	data, _ := json.Marshal(state)
+	_, err := s.db.Exec(`INSERT INTO sessions (key, updated, state) VALUES (?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET updated = excluded.updated, state = excluded.state`,
+		key, updated.UTC().Format(time.RFC3339), string(data))
+	return err
+}
+
+func (s *sqliteStore) ListSessions() ([]SessionInfo, error) {
+	rows, err := s.db.Query(`SELECT key, updated, state FROM sessions ORDER BY updated`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []SessionInfo
+	for rows.Next() {
+		var key, updated, raw string
+		if err := rows.Scan(&key, &updated, &raw); err != nil {
+			return nil, err
+		}
+		var state SessionState
+		if err := json.Unmarshal([]byte(raw), &state); err != nil {
+			continue
+		}
+		ts, _ := time.Parse(time.RFC3339, updated)
+		out = append(out, SessionInfo{Key: key, Updated: ts, State: state})
+	}
+	return out, rows.Err()
+}
+
+func (s *sqliteStore) DeleteSession(key string) error {
+	_, err := s.db.Exec(`DELETE FROM sessions WHERE key = ?`, key)
+	return err
+}
+
+func (s *sqliteStore) CacheGet(key string) *RouteResult {
+	if s.cfg.Cache.Disabled {
+		return nil
+	}
+	var e cacheEntry
+	var raw string
+	err := s.db.QueryRow(`SELECT created, model, result FROM cache WHERE key = ?`, key).Scan(&e.Created, &e.Model, &raw)
+	if err != nil {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(raw), &e.Result); err != nil || cacheExpired(e, s.cfg.Cache.ttl(), time.Now()) {
+		s.db.Exec(`DELETE FROM cache WHERE key = ?`, key)
+		return nil
+	}
+	return &e.Result
+}
+
+func (s *sqliteStore) CachePut(key, model string, result *RouteResult) {
+	if s.cfg.Cache.Disabled {
+		return
+	}
+	s.cachePut(key, cacheEntry{
+		Created: time.Now().UTC().Format(time.RFC3339),
+		Model:   model,
+		Result:  *result,
+	})
+}
+
+func (s *sqliteStore) cachePut(key string, e cacheEntry) error {
+	data, _ := json.Marshal(e.Result)
+	_, err := s.db.Exec(`INSERT INTO cache (key, created, model, result) VALUES (?, ?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET created = excluded.created, model = excluded.model, result = excluded.result`,
+		key, e.Created, e.Model, string(data))
+	if err != nil {
+		return err
+	}
+	if max := s.cfg.Cache.MaxEntries; max > 0 {
+		_, err = s.db.Exec(`DELETE FROM cache WHERE key NOT IN (SELECT key FROM cache ORDER BY created DESC LIMIT ?)`, max)
+	}
+	return err
+}
+
+func (s *sqliteStore) CacheStats() (CacheStats, error) {
+	var stats CacheStats
+	rows, err := s.db.Query(`SELECT created, model, result FROM cache`)
+	if err != nil {
+		return stats, err
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	for rows.Next() {
+		var e cacheEntry
+		var raw string
+		if err := rows.Scan(&e.Created, &e.Model, &raw); err != nil {
+			return stats, err
+		}
+		stats.Entries++
+		stats.Bytes += int64(len(raw))
+		if cacheExpired(e, s.cfg.Cache.ttl(), now) {
+			stats.Expired++
+		}
+		created, _ := time.Parse(time.RFC3339, e.Created)
+		if stats.Oldest.IsZero() || created.Before(stats.Oldest) {
+			stats.Oldest = created
+		}
+		if created.After(stats.Newest) {
+			stats.Newest = created
+		}
+	}
+	return stats, rows.Err()
+}
+
+func (s *sqliteStore) CacheClear() (int, error) {
+	res, err := s.db.Exec(`DELETE FROM cache`)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+func (s *sqliteStore) Close() error {
+	return s.db.Close()
+}
+
+// MigrateResult counts what MigrateToSQLite copied.
+type MigrateResult struct {
+	Logs     int
+	Feedback int
+	Sessions int
+	Cache    int
+	Existing int // log entries and feedback labels already in the database, skipped
+}
+
+// MigrateToSQLite copies the jsonl log (including archives), feedback, session state files,
+// and cached decisions into the SQLite database at path. Existing sessions and cache
+// entries with the same key are overwritten. Logs and feedback are appended,
+// skipping entries already in the database, so migrating twice copies nothing new.
+func MigrateToSQLite(cfg *Config, path string) (MigrateResult, error) {
+	var res MigrateResult
+	dst, err := openSQLiteStore(cfg, path)
+	if err != nil {
+		return res, err
+	}
+	defer dst.Close()
+	src := &fileStore{cfg: cfg}
+
+	entries, err := ReadLog(0)
+	if err != nil {
+		return res, fmt.Errorf("reading log: %w", err)
+	}
+	tx, err := dst.db.Begin()
+	if err != nil {
+		return res, err
+	}
+	for _, e := range entries {
+		data, _ := json.Marshal(e)
+		if dup, err := hasRow(tx, `SELECT 1 FROM logs WHERE ts = ? AND entry = ?`, e.Timestamp, string(data)); err != nil || dup {
+			if err != nil {
+				tx.Rollback()
+				return res, err
+			}
+			res.Existing++
+			continue
+		}
+		if _, err := tx.Exec(`INSERT INTO logs (ts, status, model, session_key, entry) VALUES (?, ?, ?, ?, ?)`,
+			e.Timestamp, e.Status, e.Model, e.SessionKey, string(data)); err != nil {
+			tx.Rollback()
+			return res, err
+		}
+		res.Logs++
+	}
+	if err := tx.Commit(); err != nil {
+		return res, err
+	}
+
+	feedback, err := src.ReadFeedback()
+	if err != nil {
+		return res, fmt.Errorf("reading feedback: %w", err)
+	}
+	for _, f := range feedback {
+		data, _ := json.Marshal(f)
+		if dup, err := hasRow(dst.db, `SELECT 1 FROM feedback WHERE log_id = ? AND entry = ?`, f.LogID, string(data)); err != nil {
+			return res, err
+		} else if dup {
+			res.Existing++
+			continue
+		}
+		if err := dst.AppendFeedback(f); err != nil {
+			return res, err
+		}
+		res.Feedback++
+	}
+
+	sessions, err := src.ListSessions()
+	if err != nil {
+		return res, fmt.Errorf("reading sessions: %w", err)
+	}
+	for _, si := range sessions {
+		if err := dst.saveSession(si.Key, si.State, si.Updated); err != nil {
+			return res, err
+		}
+		res.Sessions++
+	}
+
+	files, _ := filepath.Glob(filepath.Join(CacheDir(), "*.json"))
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		var e cacheEntry
+		if err := json.Unmarshal(data, &e); err != nil {
+			continue
+		}
+		key := filepath.Base(f[:len(f)-len(".json")])
+		if err := dst.cachePut(key, e); err != nil {
+			return res, err
+		}
+		res.Cache++
+	}
+	return res, nil
+}
+
+// hasRow reports whether query returns any row.
+func hasRow(q interface {
+	QueryRow(query string, args ...any) *sql.Row
+}, query string, args ...any) (bool, error) {
+	var one int
+	switch err := q.QueryRow(query, args...).Scan(&one); err {
+	case nil:
+		return true, nil
+	case sql.ErrNoRows:
+		return false, nil
+	default:
+		return false, err
+	}
+}
@@ -0,0 +1,191 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openTestStores(t *testing.T) map[string]Store {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	cfg := DefaultConfig()
+	file, err := OpenStore(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sqlCfg := DefaultConfig()
+	sqlCfg.Storage = StorageConfig{Backend: "sqlite", Path: filepath.Join(t.TempDir(), "reflex.db")}
+	db, err := OpenStore(sqlCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return map[string]Store{"jsonl": file, "sqlite": db}
+}
+
+func TestStore_LogQuery(t *testing.T) {
+	for name, store := range openTestStores(t) {
+		t.Run(name, func(t *testing.T) {
+			store.AppendLog(LogEntry{Status: "ok", Model: "m", MessageCount: 1})
+			store.AppendLog(LogEntry{Status: "skipped", Model: "m", MessageCount: 2})
+			store.AppendLog(LogEntry{Status: "ok", Model: "m", MessageCount: 3})
+
+			all, err := store.ReadLog(LogQuery{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(all) != 3 || all[0].MessageCount != 1 || all[2].MessageCount != 3 {
+				t.Fatalf("expected 3 entries oldest first, got %+v", all)
+			}
+
+			ok, _ := store.ReadLog(LogQuery{Status: "ok"})
+			if len(ok) != 2 {
+				t.Errorf("expected 2 ok entries, got %d", len(ok))
+			}
+
+			last, _ := store.ReadLog(LogQuery{Limit: 1})
+			if len(last) != 1 || last[0].MessageCount != 3 {
+				t.Errorf("expected newest entry, got %+v", last)
+			}
+
+			recent, _ := store.ReadLog(LogQuery{Since: time.Now().Add(-time.Hour)})
+			if len(recent) != 3 {
+				t.Errorf("expected 3 entries in the last hour, got %d", len(recent))
+			}
+		})
+	}
+}
+
+func TestStore_Sessions(t *testing.T) {
+	for name, store := range openTestStores(t) {
+		t.Run(name, func(t *testing.T) {
+			state := SessionState{DocsRead: []string{"docs/a.md"}, SkillsUsed: []string{"deploy"}}
+			if err := store.SaveSession("abc", state); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := store.LoadSession("abc")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got.DocsRead) != 1 || got.DocsRead[0] != "docs/a.md" || got.SkillsUsed[0] != "deploy" {
+				t.Errorf("unexpected session state: %+v", got)
+			}
+
+			missing, err := store.LoadSession("missing")
+			if err != nil || len(missing.DocsRead) != 0 {
+				t.Errorf("missing session should load empty, got %+v (%v)", missing, err)
+			}
+
+			list, _ := store.ListSessions()
+			if len(list) != 1 || list[0].Key != "abc" {
+				t.Errorf("expected one listed session, got %+v", list)
+			}
+
+			if err := store.DeleteSession("abc"); err != nil {
+				t.Fatal(err)
+			}
+			if list, _ := store.ListSessions(); len(list) != 0 {
+				t.Errorf("expected no sessions after delete, got %d", len(list))
+			}
+		})
+	}
+}
+
+func TestStore_Cache(t *testing.T) {
+	for name, store := range openTestStores(t) {
+		t.Run(name, func(t *testing.T) {
+			store.CachePut("k", "m", &RouteResult{Docs: []string{"docs/a.md"}, Skills: []string{}})
+			got := store.CacheGet("k")
+			if got == nil || got.Docs[0] != "docs/a.md" {
+				t.Fatalf("expected cache hit, got %+v", got)
+			}
+			stats, _ := store.CacheStats()
+			if stats.Entries != 1 {
+				t.Errorf("expected 1 cache entry, got %d", stats.Entries)
+			}
+			if n, _ := store.CacheClear(); n != 1 {
+				t.Errorf("expected to clear 1 entry, got %d", n)
+			}
+			if store.CacheGet("k") != nil {
+				t.Error("expected miss after clear")
+			}
+		})
+	}
+}
+
+func TestFileStore_RejectsPathLikeSessionKeys(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	store, _ := OpenStore(DefaultConfig())
+	for _, key := range []string{"", "..", "../etc/passwd", `a\b`} {
+		if err := store.SaveSession(key, SessionState{}); err == nil {
+			t.Errorf("expected error for session key %q", key)
+		}
+	}
+}
+
+func TestMigrateToSQLite(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg := DefaultConfig()
+	file, _ := OpenStore(cfg)
+	file.AppendLog(LogEntry{Status: "ok", SessionKey: "s1"})
+	file.AppendLog(LogEntry{Status: "cached", SessionKey: "s1"})
+	file.SaveSession("s1", SessionState{DocsRead: []string{"docs/a.md"}})
+	file.CachePut("k", "m", &RouteResult{Docs: []string{}, Skills: []string{}})
+	file.AppendFeedback(Feedback{LogID: "x", Verdict: FeedbackGood})
+
+	dbPath := filepath.Join(t.TempDir(), "reflex.db")
+	res, err := MigrateToSQLite(cfg, dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Logs != 2 || res.Sessions != 1 || res.Cache != 1 || res.Feedback != 1 {
+		t.Errorf("unexpected migration counts: %+v", res)
+	}
+
+	cfg.Storage = StorageConfig{Backend: "sqlite", Path: dbPath}
+	db, err := OpenStore(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	entries, _ := db.ReadLog(LogQuery{})
+	if len(entries) != 2 || entries[1].Status != "cached" {
+		t.Errorf("expected migrated log entries in order, got %+v", entries)
+	}
+	state, _ := db.LoadSession("s1")
+	if len(state.DocsRead) != 1 {
+		t.Errorf("expected migrated session, got %+v", state)
+	}
+	if db.CacheGet("k") == nil {
+		t.Error("expected migrated cache entry")
+	}
+	if _, err := os.Stat(dbPath); err != nil {
+		t.Errorf("database file should exist: %v", err)
+	}
+
+	// Migrating again copies nothing new
+	res, err = MigrateToSQLite(cfg, dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Logs != 0 || res.Feedback != 0 || res.Existing != 3 {
+		t.Errorf("second migration should skip existing entries: %+v", res)
+	}
+	if entries, _ := db.ReadLog(LogQuery{}); len(entries) != 2 {
+		t.Errorf("expected no duplicated log entries, got %d", len(entries))
+	}
+}
+
+func TestOpenStore_UnknownBackend(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Storage.Backend = "postgres"
+	if _, err := OpenStore(cfg); err == nil {
+		t.Error("expected error for unknown backend")
+	}
+}
@@ -6,6 +6,12 @@ type RouteInput struct {
 	Registry Registry       `json:"registry"`
 	Session  SessionState   `json:"session"`
 	Metadata map[string]any `json:"metadata"`
+	// SessionKey, if set, makes Reflex load and save session state in its own store
+	// instead of relying on the caller to track it.
+	SessionKey string `json:"session_key,omitempty"`
+	// Transcript is the path of the agent's transcript (Claude Code JSONL). With
+	// session_key, it is checked for reads of the docs injected on the previous turn.
+	Transcript string `json:"transcript,omitempty"`
 }
 
 // Message is a single conversation turn.
@@ -14,10 +20,11 @@ type Message struct {
 	Text string `json:"text"`
 }
 
-// Registry holds available docs and skills.
+// Registry holds available docs and skills, and items of any other kind.
 type Registry struct {
 	Docs   []RegistryDoc   `json:"docs"`
 	Skills []RegistrySkill `json:"skills"`
+	Items  []RegistryItem  `json:"items,omitempty"`
 }
 
 // RegistryDoc is a doc available for injection.
@@ -25,23 +32,73 @@ type RegistryDoc struct {
 	Path     string   `json:"path"`
 	Summary  string   `json:"summary"`
 	ReadWhen []string `json:"read_when,omitempty"`
+	SkipWhen []string `json:"skip_when,omitempty"` // requests this doc is not for; enforced after routing
+	// ConflictsWith lists alternative docs (e.g. v1 vs v2 guides) never returned together with this one
+	ConflictsWith []string `json:"conflicts_with,omitempty"`
+	AppliesTo     []string `json:"applies_to,omitempty"` // globs, e.g. "src/billing/**", matched against metadata.files
+	Requires      []string `json:"requires,omitempty"`   // docs or skills ("/name") to include with this one
+	Inject        string   `json:"inject,omitempty"`     // "always" or "session_start": injected without asking the router
+	LastUpdated   string   `json:"last_updated,omitempty"`
+	// Stale is set by discovery when files the doc references changed after it was last updated
+	Stale       bool   `json:"stale,omitempty"`
+	StaleReason string `json:"stale_reason,omitempty"`
+	// When limits the doc to matching metadata, e.g. {"branch": ["release/*"]}; see matchWhen
+	When map[string][]string `json:"when,omitempty"`
+	// Scope is the package the doc belongs to: the nearest directory above it
+	// with its own .reflex/, or empty for the project root
+	Scope string `json:"scope,omitempty"`
 }
 
 // RegistrySkill is a skill available for injection.
 type RegistrySkill struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Requires    []string `json:"requires,omitempty"` // docs or skills ("/name") to include with this one
+	Inject      string   `json:"inject,omitempty"`   // "always" or "session_start": injected without asking the router
+	// When limits the skill to matching metadata, e.g. {"language": ["go"]}; see matchWhen
+	When map[string][]string `json:"when,omitempty"`
+}
+
+// RegistryItem is an entry of any kind, e.g. a slash command, MCP tool,
+// subagent, rule, or memory file. Items of kind doc or skill are routed as
+// entries of Docs and Skills.
+type RegistryItem struct {
+	Kind        string   `json:"kind"` // see the Kind constants; other kinds are routed as-is
+	ID          string   `json:"id"`   // command name, tool name, agent name, or file path
+	Description string   `json:"description"`
+	Hints       []string `json:"hints,omitempty"` // like read_when: requests the item is for
+	// When limits the item to matching metadata; see matchWhen
+	When  map[string][]string `json:"when,omitempty"`
+	Scope string              `json:"scope,omitempty"` // package, as for RegistryDoc.Scope
 }
 
 // SessionState tracks what has already been injected this session.
 type SessionState struct {
 	DocsRead   []string `json:"docs_read"`
 	SkillsUsed []string `json:"skills_used"`
+	ItemsUsed  []string `json:"items_used,omitempty"` // other kinds, as "kind:id"
+	// Read compliance, tracked when the caller sends a transcript: docs the agent
+	// was told to read and hasn't been checked on yet, docs it didn't read, and
+	// ignored docs already injected a second time.
+	Pending  []string `json:"pending,omitempty"`
+	Ignored  []string `json:"ignored,omitempty"`
+	Reminded []string `json:"reminded,omitempty"`
 }
 
 // RouteResult is the JSON output from `reflex route`.
+// Items holds the same selections as Docs and Skills, plus any of other kinds,
+// highest score first, with the model's confidence and reason for each.
 type RouteResult struct {
-	Reasoning string   `json:"reasoning"`
-	Docs      []string `json:"docs"`
-	Skills    []string `json:"skills"`
+	Reasoning string       `json:"reasoning"`
+	Docs      []string     `json:"docs"`
+	Skills    []string     `json:"skills"`
+	Items     []RankedItem `json:"items"`
+	Content   []DocContent `json:"content,omitempty"` // inlined doc bodies, when inline output is on
+	// ByKind lists the selections of every kind, in Items order, for callers
+	// that render each kind differently
+	ByKind map[string][]string `json:"by_kind,omitempty"`
+	LogID  string              `json:"log_id,omitempty"` // log entry of this decision, for `reflex feedback`
+	// SessionKey echoes the input's session_key, telling callers Reflex keeps
+	// that session's state itself
+	SessionKey string `json:"session_key,omitempty"`
 }
@@ -18,8 +18,26 @@ from pathlib import Path
 # How many recent transcript entries to pass to the router
 LOOKBACK = 10
 
-# Max directory depth to scan for docs (relative to project root)
-MAX_DOC_DEPTH = 3
+# How many recently touched files to send as metadata.files (for applies_to docs)
+MAX_FILES = 20
+
+# How many recently used tool names to send as metadata.tools
+MAX_TOOLS = 10
+
+# Tools whose inputs name a file the agent opened or edited
+_FILE_TOOLS = {"Read", "Edit", "Write", "MultiEdit", "NotebookEdit"}
+
+# Items scored below this are offered as suggestions rather than instructions
+WEAK_SCORE = 0.7
+
+# How to present selected registry items of kinds other than doc and skill
+_KIND_TEXT = {
+    "command": "These slash commands fit this task: {}",
+    "mcp_tool": "These MCP tools are relevant to this task: {}",
+    "subagent": "Consider delegating to these subagents: {}",
+    "rule": "Follow the rules in these files; read them now: {}",
+    "memory": "Read these memory files for context on this part of the project: {}",
+}
 
 # Directories to skip when globbing for docs
 SKIP_DIRS = {
@@ -100,6 +118,56 @@ def extract_transcript(transcript_path: str, lookback: int) -> list[dict]:
     return entries[-lookback:]
 
 
+def extract_tool_activity(transcript_path: str, limit: int) -> tuple[list[str], list[str]]:
+    """Return the files most recently read or edited and the tools most recently used, newest first."""
+    try:
+        with open(transcript_path) as f:
+            lines = f.readlines()
+    except (OSError, IOError):
+        return [], []
+
+    files: list[str] = []
+    tools: list[str] = []
+    for line in reversed(lines):
+        if len(files) >= limit and len(tools) >= MAX_TOOLS:
+            break
+        try:
+            raw = json.loads(line)
+        except json.JSONDecodeError:
+            continue
+        if raw.get("type") != "assistant":
+            continue
+        content = raw.get("message", {}).get("content", "")
+        if not isinstance(content, list):
+            continue
+        for block in reversed(content):
+            if block.get("type") != "tool_use":
+                continue
+            name = block.get("name")
+            if isinstance(name, str) and name and name not in tools:
+                tools.append(name)
+            if name not in _FILE_TOOLS:
+                continue
+            tool_input = block.get("input") or {}
+            path = tool_input.get("file_path") or tool_input.get("notebook_path")
+            if isinstance(path, str) and path and path not in files:
+                files.append(path)
+    return files[:limit], tools[:MAX_TOOLS]
+
+
+def git_branch(project_dir: Path) -> str:
+    """Return the current git branch of the project, or "" outside a repo or on a detached HEAD."""
+    try:
+        result = subprocess.run(
+            ["git", "rev-parse", "--abbrev-ref", "HEAD"],
+            cwd=str(project_dir), capture_output=True, text=True, timeout=2,
+        )
+    except (OSError, subprocess.TimeoutExpired):
+        return ""
+    branch = result.stdout.strip()
+    return branch if result.returncode == 0 and branch != "HEAD" else ""
+
+
 def parse_frontmatter(file_path: Path) -> dict:
     """Parse YAML frontmatter from a markdown file. Returns dict of key->value."""
     try:
@@ -200,8 +268,6 @@ def discover_docs(project_dir: Path) -> list[dict]:
     for md_file in sorted(project_dir.rglob("*.md")):
         if should_skip(md_file):
             continue
-        if len(md_file.relative_to(project_dir).parts) - 1 > MAX_DOC_DEPTH:
-            continue
 
         fm = parse_frontmatter(md_file)
         summary = fm.get("summary", "")
@@ -260,8 +326,26 @@ def find_reflex_bin() -> str:
     return "reflex"  # will fail with clear FileNotFoundError
 
 
-def call_reflex(payload: dict) -> dict:
-    """Call `reflex route` with the given payload."""
+def discover_via_reflex(project_dir: Path) -> dict:
+    """Run `reflex discover` in the project. Returns {} if the binary is missing, old, or fails."""
+    try:
+        result = subprocess.run(
+            [find_reflex_bin(), "discover"],
+            cwd=str(project_dir),
+            capture_output=True,
+            text=True,
+            timeout=5,
+        )
+        if result.returncode != 0:
+            return {}
+        registry = json.loads(result.stdout.strip())
+        return registry if isinstance(registry, dict) else {}
+    except (OSError, subprocess.TimeoutExpired, json.JSONDecodeError):
+        return {}
+
+
+def call_reflex(payload: dict, project_dir: Path) -> dict:
+    """Call `reflex route` with the given payload, from the project root so its .reflex/config.yaml applies."""
     empty = {"docs": [], "skills": []}
     reflex_bin = find_reflex_bin()
 
@@ -269,6 +353,7 @@ def call_reflex(payload: dict) -> dict:
         result = subprocess.run(
             [reflex_bin, "route"],
             input=json.dumps(payload),
+            cwd=str(project_dir),
             capture_output=True,
             text=True,
             timeout=15,
@@ -304,12 +389,14 @@ def main():
     project_dir = Path(os.environ.get("CLAUDE_PROJECT_DIR") or input_data.get("cwd") or ".")
     state_dir = Path.home() / ".config" / "reflex" / "state"
 
-    # Auto-discover registry — no config file needed
-    docs = discover_docs(project_dir)
-    skills = discover_skills(project_dir)
-    if not docs and not skills:
+    # Auto-discover registry — no config file needed. `reflex discover` also splits
+    # large docs into sections; older binaries fall back to the scan below.
+    registry = discover_via_reflex(project_dir) or {
+        "docs": discover_docs(project_dir),
+        "skills": discover_skills(project_dir),
+    }
+    if not registry.get("docs") and not registry.get("skills") and not registry.get("items"):
         sys.exit(0)
-    registry = {"docs": docs, "skills": skills}
 
     # Extract recent conversation from transcript
     messages = extract_transcript(transcript_path, LOOKBACK) if transcript_path else []
@@ -319,33 +406,80 @@ def main():
     if current_prompt and not _is_noise(current_prompt):
         messages.append({"type": "user", "text": current_prompt[:2000]})
 
-    # Load session state
+    # Agent context: files touched select docs scoped to them via applies_to; cwd keeps
+    # docs of other monorepo packages out; branch, cwd, and tools are shown to the
+    # router and matched by `when` conditions
+    files, tools = extract_tool_activity(transcript_path, MAX_FILES) if transcript_path else ([], [])
+    metadata = {"files": files, "tools": tools, "cwd": input_data.get("cwd") or str(project_dir)}
+    branch = git_branch(project_dir)
+    if branch:
+        metadata["branch"] = branch
+
+    # Call reflex — session_key makes reflex load and save injection history in its own store;
+    # the transcript lets it check whether the docs injected last turn were actually read.
+    # The locally saved session covers older binaries that ignore session_key.
     session = load_session_state(state_dir, session_key)
-
-    # Call reflex
     payload = {
         "messages": messages,
         "registry": registry,
         "session": session,
-        "metadata": {},
+        "session_key": session_key,
+        "metadata": metadata,
     }
+    if transcript_path:
+        payload["transcript"] = transcript_path
 
-    result = call_reflex(payload)
+    result = call_reflex(payload, project_dir)
     docs = result.get("docs", [])
     skills = result.get("skills", [])
+    # Commands, MCP tools, subagents, rules, and memory files (older binaries return no by_kind)
+    others = {k: v for k, v in (result.get("by_kind") or {}).items() if k not in ("doc", "skill") and v}
 
-    if not docs and not skills:
+    if not docs and not skills and not others:
         sys.exit(0)
 
-    # Update session state
-    session["docs_read"] = list(set(session.get("docs_read", []) + docs))
-    session["skills_used"] = list(set(session.get("skills_used", []) + skills))
-    save_session_state(state_dir, session_key, session)
+    # Binaries that echo session_key have saved the session; older ones need it saved here
+    if not result.get("session_key"):
+        session["docs_read"] = list(set(session.get("docs_read", []) + docs))
+        session["skills_used"] = list(set(session.get("skills_used", []) + skills))
+        save_session_state(state_dir, session_key, session)
+
+    # Docs injected again because the agent didn't read them last time (compliance.reinject)
+    reminders = [i.get("name") for i in result.get("items") or [] if i.get("kind") == "doc" and i.get("reminder")]
+    docs = [d for d in docs if d not in reminders]
+
+    # Split weak matches out so they read as suggestions (older binaries return no items)
+    scores = {(i.get("kind"), i.get("name")): i.get("score", 1) for i in result.get("items") or []}
+    weak_docs = [d for d in docs if scores.get(("doc", d), 1) < WEAK_SCORE]
+    weak_skills = [s for s in skills if scores.get(("skill", s), 1) < WEAK_SCORE]
+    docs = [d for d in docs if d not in weak_docs]
+    skills = [s for s in skills if s not in weak_skills]
+    # Docs whose code changed since they were last updated get a caution
+    stale = {i.get("name") for i in result.get("items") or [] if i.get("kind") == "doc" and i.get("stale")}
+
+    def mark(doc: str) -> str:
+        return f"{doc} (may be outdated; verify against the code)" if doc in stale else doc
+
+    # Docs inlined by reflex (inline.enabled) are injected as content instead of a read instruction
+    content = [c for c in result.get("content") or [] if c.get("path") in docs]
+    inlined = {c["path"] for c in content}
+    docs = [d for d in docs if d not in inlined]
 
     # Inject context
     parts = []
+    for c in content:
+        note = ' excerpt="true"' if c.get("truncated") else ""
+        note += ' stale="true"' if c["path"] in stale else ""
+        parts.append(f'<doc path="{c["path"]}"{note}>\n{c.get("text", "")}\n</doc>')
+    if content:
+        parts.insert(0, "Project docs relevant to this request:")
+    if reminders:
+        parts.append(
+            "You were told to read these files on the previous turn and did not. Read them now, "
+            "before anything else:\n" + "\n".join(f"- {mark(d)}" for d in reminders)
+        )
     if docs:
-        doc_list = "\n".join(f"- {d}" for d in docs)
+        doc_list = "\n".join(f"- {mark(d)}" for d in docs)
         parts.append(
             f"Before responding, read these files. Do not skip this even if you think "
             f"you already know the content — read them now:\n{doc_list}"
@@ -353,6 +487,13 @@ def main():
     if skills:
         skill_list = ", ".join("/" + s for s in skills)
         parts.append(f"Use the {skill_list} skill for this task.")
+    for kind, ids in others.items():
+        names = ", ".join("/" + i if kind == "command" else i for i in ids)
+        text = _KIND_TEXT.get(kind)
+        parts.append(text.format(names) if text else f"Also relevant ({kind}): {names}")
+    if weak_docs or weak_skills:
+        maybe = [f"- {mark(d)}" for d in weak_docs] + [f"- /{s} skill" for s in weak_skills]
+        parts.append("These may also be relevant; check them if the task touches their area:\n" + "\n".join(maybe))
 
     output = {
         "hookSpecificOutput": {
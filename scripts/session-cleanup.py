@@ -2,12 +2,16 @@
 """
 Reflex session cleanup hook.
 
-Deletes the current session's state file from ~/.config/reflex/state/ on
-session start (startup, clear, compact) and session end, so each session
-starts with a fresh injection history.
+Clears the current session's injection history on session start (startup,
+clear, compact) and session end, so each session starts fresh. Uses
+`reflex session clear` so it works with any storage backend, falling back to
+deleting the state file from ~/.config/reflex/state/ directly.
 """
 
 import json
+import os
+import shutil
+import subprocess
 import sys
 from pathlib import Path
 
@@ -29,14 +33,14 @@ def main():
     elif event != "SessionEnd":
         sys.exit(0)
 
-    state_dir = Path.home() / ".config" / "reflex" / "state"
-
     # Derive session key the same way the main hook does
     transcript_path = input_data.get("transcript_path", "")
     session_key = Path(transcript_path).stem if transcript_path else input_data.get("session_id", "")
+    if not session_key:
+        sys.exit(0)
 
-    if session_key:
-        session_file = state_dir / f"{session_key}.json"
+    if not clear_via_reflex(session_key):
+        session_file = Path.home() / ".config" / "reflex" / "state" / f"{session_key}.json"
         if session_file.exists():
             try:
                 session_file.unlink()
@@ -46,5 +50,26 @@ def main():
     sys.exit(0)
 
 
+def clear_via_reflex(session_key: str) -> bool:
+    """Run `reflex session clear`. Returns False if the binary is missing or fails."""
+    reflex_bin = os.environ.get("REFLEX_BIN") or shutil.which("reflex")
+    if not reflex_bin:
+        for candidate in (Path.home() / "go" / "bin" / "reflex", Path.home() / ".local" / "bin" / "reflex"):
+            if candidate.exists():
+                reflex_bin = str(candidate)
+                break
+    if not reflex_bin:
+        return False
+    try:
+        result = subprocess.run(
+            [reflex_bin, "session", "clear", session_key],
+            capture_output=True,
+            timeout=4,
+        )
+        return result.returncode == 0
+    except (OSError, subprocess.TimeoutExpired):
+        return False
+
+
 if __name__ == "__main__":
     main()